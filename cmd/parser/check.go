@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/loader"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+)
+
+// checkReferences parses every table and reports references to undefined names.
+func checkReferences(ctx context.Context, mods *loader.Loader, opts []parser.LexerOption) {
+	idx := index.New()
+	for _, file := range mods.Files() {
+		def, found := structs.LookupTable(file.Name)
+		if !found || len(def.Symbols) == 0 {
+			continue
+		}
+
+		content, err := file.Read()
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("Error: Failed to open file: %+v\n", err))
+			os.Exit(1)
+		}
+
+		nodes, err := parseDefinition(ctx, def, string(content), opts...)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("Failed to parse %s: %+v\n", file, err))
+			os.Exit(1)
+		}
+
+		idx.Add(file.String(), string(content), nodes, def.Symbols)
+	}
+
+	problems := idx.Check()
+	files := make([]string, 0, len(problems))
+	for file := range problems {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		for _, err := range problems[file] {
+			fmt.Printf("%s: %s\n", file, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseDefinition parses content with def's schema or in generic mode if it doesn't have one.
+func parseDefinition(ctx context.Context, def structs.TableDefinition, content string, opts ...parser.LexerOption) ([]*parser.Node, error) {
+	if def.Schema == nil {
+		return parser.ParseGeneric(ctx, content, opts...)
+	}
+
+	return parser.ParseTable(parser.NewLexer(ctx, strings.NewReader(content), opts...), def.Schema())
+}
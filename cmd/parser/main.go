@@ -4,32 +4,220 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/ngld/fso-table-parser/pkg/loader"
+	"github.com/ngld/fso-table-parser/pkg/merge"
 	"github.com/ngld/fso-table-parser/pkg/parser"
 	"github.com/ngld/fso-table-parser/pkg/structs"
+	"github.com/ngld/fso-table-parser/pkg/vp"
 )
 
+const usage = `Usage:
+  parser [-version x.y.z] <path to .tbl or .tbm>  Parse a single table and print the result as JSON.
+                                                  Tables inside VPs can be read with paths like
+                                                  mv_core.vp/data/tables/ships.tbl
+  parser files [-mod a,b,c] <root>                List the tables in the order FSO loads them
+  parser merge [-mod a,b,c] <root> [table]        Merge a base table (default: ships.tbl) with all of
+                                                  its modular tables and print the result
+  parser check [-mod a,b,c] <root>                Report references to undefined ship classes,
+                                                  weapons, armor types, damage types, species and
+                                                  engine washes
+  parser strings [-mod a,b,c] <root>              Report XSTR IDs used with different texts as well as
+                                                  missing and stale translations in tstrings.tbl.
+                                                  Missions in data/missions are checked as well
+  parser rename [-n] <folder> <kind> <old> <new>  Rename a ship, weapon, armor, damage, species or
+                                                  wash in every table, mission and campaign inside
+                                                  the folder. -n only prints the changes
+  parser pack <folder> <output.vp>                Pack the folder's content into a VP archive
+
+<root> is the FreeSpace folder containing the mod folders. -mod works like the engine's option;
+dependencies from each mod's mod.ini are added automatically. -version x.y.z selects the engine
+version deciding which ;;FSO x.y.z;; lines are active (default: the latest version).
+`
+
 func main() {
 	ctx := context.Background()
 	if len(os.Args) < 2 {
-		os.Stderr.WriteString("Usage: parser <path to .tbl or .tbm>\n")
+		os.Stderr.WriteString(usage)
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "files":
+		mods, _, _ := openMods(os.Args[2:])
+		defer mods.Close()
+		for _, file := range mods.Files() {
+			fmt.Println(file)
+		}
+	case "merge":
+		mods, opts, args := openMods(os.Args[2:])
+		defer mods.Close()
+		table := "ships.tbl"
+		if len(args) > 0 {
+			table = args[0]
+		}
+
+		mergeTables(ctx, mods, table, opts)
+	case "check":
+		mods, opts, _ := openMods(os.Args[2:])
+		defer mods.Close()
+		checkReferences(ctx, mods, opts)
+	case "strings":
+		mods, opts, _ := openMods(os.Args[2:])
+		defer mods.Close()
+		checkStrings(ctx, mods, opts)
+	case "rename":
+		renameSymbol(ctx, os.Args[2:])
+	case "pack":
+		if len(os.Args) < 4 {
+			os.Stderr.WriteString(usage)
+			os.Exit(2)
+		}
+
+		packFolder(os.Args[2], os.Args[3])
+	default:
+		parseFile(ctx, os.Args[1:])
+	}
+}
+
+// openMods parses the common [-mod a,b,c] [-version x.y.z] <root> arguments and returns the lexer
+// options for the selected engine version and the remaining arguments.
+func openMods(args []string) (*loader.Loader, []parser.LexerOption, []string) {
+	flags := flag.NewFlagSet("parser", flag.ExitOnError)
+	flags.Usage = func() { os.Stderr.WriteString(usage) }
+	modList := flags.String("mod", "", "comma separated list of mods")
+	version := flags.String("version", "", "engine version used for version comments")
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		os.Stderr.WriteString(usage)
 		os.Exit(2)
 	}
 
-	content, err := os.ReadFile(os.Args[1])
+	mods := make([]string, 0)
+	for _, mod := range strings.Split(*modList, ",") {
+		if mod = strings.TrimSpace(mod); mod != "" {
+			mods = append(mods, mod)
+		}
+	}
+
+	result, err := loader.New(os.DirFS(flags.Arg(0)), mods)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Error: Failed to load mods: %+v\n", err))
+		os.Exit(1)
+	}
+
+	return result, lexerOptions(*version), flags.Args()[1:]
+}
+
+// lexerOptions returns the options for the -version flag. The latest version is used if it's
+// empty.
+func lexerOptions(text string) []parser.LexerOption {
+	return []parser.LexerOption{parser.WithEngineVersion(engineVersion(text))}
+}
+
+// engineVersion parses the -version flag. The latest version is used if it's empty.
+func engineVersion(text string) parser.Version {
+	if text == "" {
+		return parser.LatestVersion
+	}
+
+	version, err := parser.ParseVersion(text)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Error: %s\n", err))
+		os.Exit(2)
+	}
+
+	return version
+}
+
+func parseFile(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("parser", flag.ExitOnError)
+	flags.Usage = func() { os.Stderr.WriteString(usage) }
+	version := flags.String("version", "", "engine version used for version comments")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		os.Stderr.WriteString(usage)
+		os.Exit(2)
+	}
+
+	path := flags.Arg(0)
+	opts := lexerOptions(*version)
+	content, err := readInput(path)
 	if err != nil {
 		os.Stderr.WriteString(fmt.Sprintf("Error: Failed to open file: %+v\n", err))
 		os.Exit(1)
 	}
 
-	lexer := parser.NewLexer(ctx, strings.NewReader(string(content)))
-	results := make([]interface{}, 0)
-	for _, field := range structs.NewShipsTable() {
-		result, err := field.Parse(lexer)
+	schema := structs.LookupSchema(path)
+	var results []*parser.Node
+	if schema == nil {
+		results, err = parser.ParseGeneric(ctx, string(content), opts...)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("Failed to parse %s: %+v\n", path, err))
+			os.Exit(1)
+		}
+	} else {
+		results = parseWithSchema(ctx, string(content), schema, opts)
+	}
+
+	output, err := json.Marshal(results)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Failed to generate JSON: %+v\n", err))
+		os.Exit(1)
+	}
+
+	fmt.Print(string(output))
+}
+
+// readInput reads a file from disk or from inside a VP archive if path contains a .vp folder.
+func readInput(path string) ([]byte, error) {
+	lower := strings.ToLower(filepath.ToSlash(path))
+	idx := strings.Index(lower, ".vp/")
+	if idx == -1 {
+		return os.ReadFile(path)
+	}
+
+	archive, err := vp.OpenFile(path[:idx+3])
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return archive.ReadFile(filepath.ToSlash(path[idx+4:]))
+}
+
+func packFolder(folder, output string) {
+	file, err := os.Create(output)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Error: Failed to create %s: %+v\n", output, err))
+		os.Exit(1)
+	}
+
+	err = vp.Write(file, os.DirFS(folder))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(output)
+		os.Stderr.WriteString(fmt.Sprintf("Error: Failed to write %s: %+v\n", output, err))
+		os.Exit(1)
+	}
+}
+
+func parseWithSchema(ctx context.Context, content string, schema []parser.ContainerItem, opts []parser.LexerOption) []*parser.Node {
+	lexer := parser.NewLexer(ctx, strings.NewReader(content), opts...)
+	results := make([]*parser.Node, 0)
+	for _, field := range schema {
+		nodes, err := field.Parse(lexer)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
 				os.Stderr.WriteString(fmt.Sprintf("Failed to parse %s: reached end of file before the section was read or an error ocurred during this section.\n", field.Name))
@@ -45,16 +233,38 @@ func main() {
 			os.Exit(1)
 		}
 
-		if result != nil {
-			results = append(results, result)
+		results = append(results, nodes...)
+	}
+
+	return results
+}
+
+func mergeTables(ctx context.Context, mods *loader.Loader, table string, opts []parser.LexerOption) {
+	def, found := structs.LookupTable(table)
+	if !found || def.Schema == nil {
+		os.Stderr.WriteString(fmt.Sprintf("Error: Merging %s is not supported.\n", table))
+		os.Exit(1)
+	}
+
+	schema := def.Schema()
+	merger := merge.NewMerger(schema)
+	for _, file := range mods.Table(def) {
+		err := merger.ApplyFile(ctx, file.FS, file.Path, file.String(), opts...)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("Error: Failed to load tables: %+v\n", err))
+			os.Exit(1)
 		}
 	}
 
-	output, err := json.Marshal(results)
+	for _, err := range merger.Warnings() {
+		if !errors.Is(err, io.EOF) {
+			os.Stderr.WriteString(fmt.Sprintf("%s\n", err))
+		}
+	}
+
+	err := parser.WriteTable(os.Stdout, schema, merger.Result())
 	if err != nil {
-		os.Stderr.WriteString(fmt.Sprintf("Failed to generate JSON: %+v\n", err))
+		os.Stderr.WriteString(fmt.Sprintf("Failed to write table: %+v\n", err))
 		os.Exit(1)
 	}
-
-	fmt.Print(string(output))
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+)
+
+var renameKinds = map[string]index.Kind{
+	"ship":    index.ShipClass,
+	"weapon":  index.Weapon,
+	"armor":   index.ArmorType,
+	"species": index.Species,
+	"wash":    index.EngineWash,
+	"damage":  index.DamageType,
+}
+
+// renameSymbol renames a ship class, weapon, ... in every loose table, mission and campaign below a
+// folder.
+func renameSymbol(ctx context.Context, args []string) {
+	flags := flag.NewFlagSet("rename", flag.ExitOnError)
+	flags.Usage = func() { os.Stderr.WriteString(usage) }
+	dryRun := flags.Bool("n", false, "only print the changes")
+	version := flags.String("version", "", "engine version used for version comments")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 4 {
+		os.Stderr.WriteString(usage)
+		os.Exit(2)
+	}
+
+	kind, ok := renameKinds[strings.ToLower(flags.Arg(1))]
+	if !ok {
+		os.Stderr.WriteString(fmt.Sprintf("Error: Unknown kind %s. Use ship, weapon, armor, species, wash or damage.\n", flags.Arg(1)))
+		os.Exit(2)
+	}
+
+	err := renameInFolder(ctx, os.Stdout, flags.Arg(0), kind, flags.Arg(2), flags.Arg(3), *dryRun, engineVersion(*version))
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("Error: %s\n", err))
+		os.Exit(1)
+	}
+}
+
+// renameInFolder renames name to newName in the tables, missions and campaigns below folder and
+// prints every change to out. Files are only written if dryRun is false. It refuses to rename
+// anything if a mission or campaign still uses name in a place the index doesn't cover, e.g. as a
+// SEXP argument. Lines which version disables with version comments are renamed as well.
+func renameInFolder(ctx context.Context, out io.Writer, folder string, kind index.Kind, name, newName string, dryRun bool, version parser.Version) error {
+	idx := index.New()
+	contents := make(map[string]string)
+	sexpFiles := make(map[string]string)
+	err := filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rules := structs.LookupSymbols(entry.Name())
+		if len(rules) == 0 {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		def, _ := structs.LookupTable(entry.Name())
+		nodes, err := parseDefinition(ctx, def, string(content), parser.WithEngineVersion(version))
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		inactive, err := structs.InactiveSymbols(ctx, path, string(content), version)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		idx.Add(path, string(content), nodes, rules)
+		idx.AddSymbols(inactive)
+		contents[path] = string(content)
+		if structs.UsesSexps(path) {
+			sexpFiles[path] = string(content)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	edits, err := idx.Rename(kind, name, newName, index.RefuseUnindexedUses(sexpFiles))
+	if err != nil {
+		return err
+	}
+
+	byFile := make(map[string][]index.Edit)
+	for _, edit := range edits {
+		byFile[edit.File] = append(byFile[edit.File], edit)
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		for _, edit := range byFile[file] {
+			fmt.Fprintf(out, "%s:%d: %s -> %s\n", file, edit.Range[0], name, newName)
+		}
+
+		if dryRun {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err == nil {
+			err = os.WriteFile(file, []byte(index.ApplyEdits(contents[file], byFile[file])), info.Mode())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+
+	return nil
+}
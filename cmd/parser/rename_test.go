@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestRenameDryRun(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"data/tables/mymod-shp.tbm": "#Ship Classes\n" +
+			"$Name: GTF Ulysses\n" +
+			"$Name: GTF Ulysses#2\n" +
+			"+Use Template: GTF Ulysses\n" +
+			"$Name: GTF Ulysses#3\n" +
+			";;FSO 3.7.4;; +Use Template: GTF Ulysses\n" +
+			"$Name: GTF Ulysses#4\n" +
+			";;!FSO 3.7.4;; +Use Template: GTF Ulysses\n" +
+			"#End\n",
+		"data/missions/sm1-01.fs2": "#Objects\n" +
+			"\n" +
+			"$Name: Alpha 1\n" +
+			"$Class: GTF Ulysses\n" +
+			"$Team: Friendly\n" +
+			"\n" +
+			"#Players\n" +
+			"\n" +
+			"$Ship Choices: (\n" +
+			"\t\"GTF Ulysses\"\t4\n" +
+			")\n",
+		"data/missions/freespace2.fc2": "$Name: FreeSpace 2\n" +
+			"$Type: single\n" +
+			"+Starting Ships: ( \"GTF Ulysses\" \"GTF Hercules\" )\n" +
+			"\n" +
+			"#Missions\n" +
+			"$Mission: sm1-01.fs2\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	err := renameInFolder(context.Background(), &out, root, index.ShipClass, "gtf ulysses", "GTF Odysseus", true, parser.LatestVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	campaignPath := filepath.Join(root, "data", "missions", "freespace2.fc2")
+	missionPath := filepath.Join(root, "data", "missions", "sm1-01.fs2")
+	tablePath := filepath.Join(root, "data", "tables", "mymod-shp.tbm")
+	expected := []string{
+		campaignPath + ":3: gtf ulysses -> GTF Odysseus",
+		missionPath + ":4: gtf ulysses -> GTF Odysseus",
+		missionPath + ":10: gtf ulysses -> GTF Odysseus",
+		tablePath + ":2: gtf ulysses -> GTF Odysseus",
+		tablePath + ":4: gtf ulysses -> GTF Odysseus",
+		tablePath + ":6: gtf ulysses -> GTF Odysseus",
+		// Only older engine builds use this line
+		tablePath + ":8: gtf ulysses -> GTF Odysseus",
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected\n%s\nbut got\n%s", strings.Join(expected, "\n"), out.String())
+	}
+
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s: a dry run must not change files", name)
+		}
+	}
+
+	err = renameInFolder(context.Background(), &out, root, index.ShipClass, "GTF Ulysses", "GTF Ulysses#2", true, parser.LatestVersion)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error for an existing name but got %v", err)
+	}
+
+	sexpPath := filepath.Join(root, "data", "missions", "sm1-02.fs2")
+	sexp := "#Events\n" +
+		"\n" +
+		"$Formula: ( when\n" +
+		"   ( is-ship-class \"GTF Ulysses\" \"Alpha 1\" )\n" +
+		")\n"
+	if err := os.WriteFile(sexpPath, []byte(sexp), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = renameInFolder(context.Background(), &out, root, index.ShipClass, "GTF Ulysses", "GTF Odysseus", false, parser.LatestVersion)
+	if err == nil || !strings.Contains(err.Error(), sexpPath+":4") {
+		t.Errorf("expected an error for the SEXP argument but got %v", err)
+	}
+	if data, _ := os.ReadFile(missionPath); string(data) != files["data/missions/sm1-01.fs2"] {
+		t.Error("files must not change if the rename is refused")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ngld/fso-table-parser/pkg/l10n"
+	"github.com/ngld/fso-table-parser/pkg/loader"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+)
+
+// checkStrings collects the XSTRs from every table and mission and reports conflicting IDs as well
+// as missing and stale translations.
+func checkStrings(ctx context.Context, mods *loader.Loader, opts []parser.LexerOption) {
+	catalog := l10n.New()
+	for _, file := range append(mods.Files(), mods.Missions()...) {
+		content, err := file.Read()
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("Error: Failed to open file: %+v\n", err))
+			os.Exit(1)
+		}
+
+		if l10n.IsTranslationTable(file.Name) {
+			err = catalog.AddTranslations(file.String(), string(content))
+			if err != nil {
+				os.Stderr.WriteString(fmt.Sprintf("%s\n", err))
+				os.Exit(1)
+			}
+			continue
+		}
+
+		def, _ := structs.LookupTable(file.Name)
+		nodes, err := parseDefinition(ctx, def, string(content), opts...)
+		if err != nil {
+			os.Stderr.WriteString(fmt.Sprintf("Failed to parse %s: %+v\n", file, err))
+			os.Exit(1)
+		}
+
+		catalog.Add(file.String(), nodes)
+	}
+
+	problems := catalog.Check()
+	files := make([]string, 0, len(problems))
+	for file := range problems {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		for _, err := range problems[file] {
+			fmt.Printf("%s: %s\n", file, err)
+		}
+	}
+
+	fmt.Printf("%d strings, languages: %v\n", len(catalog.Strings()), catalog.Languages())
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
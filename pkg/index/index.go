@@ -0,0 +1,512 @@
+// Package index collects the names defined and referenced by a set of parsed tables (ship classes,
+// weapons, armor types, ...) and checks that every reference can be resolved.
+package index
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/rotisserie/eris"
+)
+
+type Kind string
+
+const (
+	ShipClass  Kind = "ship class"
+	Weapon     Kind = "weapon"
+	ArmorType  Kind = "armor type"
+	Species    Kind = "species"
+	EngineWash Kind = "engine wash"
+	DamageType Kind = "damage type"
+)
+
+// builtins lists names that exist even if no table defines them.
+var builtins = map[Kind][]string{
+	// The engine falls back to its built-in species_defs.tbl if the mod doesn't provide one.
+	Species: {"Terran", "Vasudan", "Shivan"},
+}
+
+// Rule marks the values of matching labels as definitions or references of a kind of symbol.
+//
+// Path is matched against the end of a node's label path, ignoring case. {"#Armor Type", "$Name"}
+// matches every $Name in the #Armor Type section while {"$Armor Type"} matches $Armor Type
+// anywhere in the table.
+type Rule struct {
+	Path       []string
+	Kind       Kind
+	Definition bool
+}
+
+// Symbol is a single definition or reference.
+type Symbol struct {
+	Kind       Kind
+	Name       string
+	File       string
+	Range      [4]int
+	Definition bool
+	// Inactive symbols are on lines which the selected engine version disables with version
+	// comments. Only Rename uses them so that the other engine builds keep working.
+	Inactive bool
+}
+
+// Index stores the symbols of all added files.
+type Index struct {
+	symbols []Symbol
+}
+
+func New() *Index {
+	return &Index{
+		symbols: make([]Symbol, 0),
+	}
+}
+
+// Add collects the symbols from nodes according to rules. content has to be the source of nodes;
+// it's used to locate the individual items of list values.
+func (i *Index) Add(file, content string, nodes []*parser.Node, rules []Rule) {
+	i.symbols = append(i.symbols, collect(file, content, nodes, rules, nil)...)
+}
+
+// AddSymbols adds symbols collected elsewhere, e.g. by InactiveSymbols.
+func (i *Index) AddSymbols(symbols []Symbol) {
+	i.symbols = append(i.symbols, symbols...)
+}
+
+// InactiveSymbols collects the symbols from nodes which start on one of the inactive regions and
+// marks them as inactive. nodes has to be parsed with an engine version that enables some of these
+// regions; the symbols of the other regions are simply missing.
+func InactiveSymbols(file, content string, nodes []*parser.Node, rules []Rule, regions []parser.VersionRegion) []Symbol {
+	lines := make(map[int]bool)
+	for _, region := range regions {
+		if !region.Active {
+			lines[region.Range[0]] = true
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	result := collect(file, content, nodes, rules, func(node *parser.Node) bool {
+		return lines[node.Range[0]]
+	})
+	for idx := range result {
+		result[idx].Inactive = true
+	}
+
+	return result
+}
+
+// collect returns the symbols from nodes according to rules. If filter is set, only the values of
+// nodes it accepts are collected.
+func collect(file, content string, nodes []*parser.Node, rules []Rule, filter func(node *parser.Node) bool) []Symbol {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	result := make([]Symbol, 0)
+	lines := strings.Split(content, "\n")
+	path := make([]string, 0)
+	var visit func(node *parser.Node)
+	visit = func(node *parser.Node) {
+		if node.Kind == parser.ValueNode {
+			return
+		}
+
+		path = append(path, node.Label)
+		for _, rule := range rules {
+			if matchPath(path, rule.Path) && (filter == nil || filter(node)) {
+				result = append(result, valueSymbols(file, lines, node, rule)...)
+			}
+		}
+
+		for _, child := range node.Children {
+			visit(child)
+		}
+		path = path[:len(path)-1]
+	}
+
+	for _, node := range nodes {
+		visit(node)
+	}
+
+	return result
+}
+
+func valueSymbols(file string, lines []string, node *parser.Node, rule Rule) []Symbol {
+	value := node.InlineValue()
+	valueRange := node.InlineValueRange()
+	names := valueNames(value)
+
+	result := make([]Symbol, 0, len(names))
+	var ranges [][4]int
+	if _, isString := value.(string); isString {
+		ranges = [][4]int{valueRange}
+	} else {
+		ranges = findRanges(lines, valueRange, names)
+	}
+
+	for idx, name := range names {
+		if name == "" {
+			continue
+		}
+
+		symbolRange := ranges[idx]
+		if symbolRange == ([4]int{}) {
+			// Without a location, renaming the symbol would replace the whole list
+			continue
+		}
+
+		if rule.Definition && strings.HasPrefix(name, "@") {
+			// FSO strips the @ which used to mark entries that were only parsed in demo builds.
+			name = name[1:]
+			if symbolRange[0] == symbolRange[2] {
+				symbolRange[1]++
+			}
+		}
+
+		result = append(result, Symbol{
+			Kind:       rule.Kind,
+			Name:       name,
+			File:       file,
+			Range:      symbolRange,
+			Definition: rule.Definition,
+		})
+	}
+
+	return result
+}
+
+// Remove drops all symbols collected from file.
+func (i *Index) Remove(file string) {
+	result := i.symbols[:0]
+	for _, symbol := range i.symbols {
+		if symbol.File != file {
+			result = append(result, symbol)
+		}
+	}
+	i.symbols = result
+}
+
+// Symbols returns all collected symbols including inactive ones.
+func (i *Index) Symbols() []Symbol {
+	return i.symbols
+}
+
+// Definitions returns all active definitions of the given name.
+func (i *Index) Definitions(kind Kind, name string) []Symbol {
+	return i.filter(kind, name, true, false)
+}
+
+// References returns all active references to the given name.
+func (i *Index) References(kind Kind, name string) []Symbol {
+	return i.filter(kind, name, false, false)
+}
+
+func (i *Index) filter(kind Kind, name string, definition, inactive bool) []Symbol {
+	result := make([]Symbol, 0)
+	for _, symbol := range i.symbols {
+		if symbol.Inactive && !inactive {
+			continue
+		}
+
+		if symbol.Kind == kind && symbol.Definition == definition && strings.EqualFold(symbol.Name, name) {
+			result = append(result, symbol)
+		}
+	}
+
+	return result
+}
+
+// SymbolAt returns the active symbol in file whose range contains the given position. line starts
+// at 1 and col at 0 like parser ranges.
+func (i *Index) SymbolAt(file string, line, col int) (Symbol, bool) {
+	for _, symbol := range i.symbols {
+		if symbol.File != file || symbol.Inactive {
+			continue
+		}
+
+		if (line > symbol.Range[0] || (line == symbol.Range[0] && col >= symbol.Range[1])) &&
+			(line < symbol.Range[2] || (line == symbol.Range[2] && col <= symbol.Range[3])) {
+			return symbol, true
+		}
+	}
+
+	return Symbol{}, false
+}
+
+// Search returns the active definitions whose name contains query, ignoring case. An empty query
+// returns every definition.
+func (i *Index) Search(query string) []Symbol {
+	query = strings.ToLower(query)
+	result := make([]Symbol, 0)
+	for _, symbol := range i.symbols {
+		if symbol.Definition && !symbol.Inactive && strings.Contains(strings.ToLower(symbol.Name), query) {
+			result = append(result, symbol)
+		}
+	}
+
+	return result
+}
+
+// Edit replaces Range in File with NewText.
+type Edit struct {
+	File    string
+	Range   [4]int
+	NewText string
+}
+
+// maxNameLength is the longest name the engine accepts (NAME_LENGTH - 1). Longer names are
+// truncated.
+const maxNameLength = 31
+
+// RenameOption configures Rename.
+type RenameOption func(*renameConfig)
+
+type renameConfig struct {
+	checkedFiles map[string]string
+}
+
+// RefuseUnindexedUses makes Rename fail if one of files still contains the old name in quotes at a
+// position none of the edits cover, e.g. as a SEXP argument in a mission. files maps the path of
+// each file to its content.
+func RefuseUnindexedUses(files map[string]string) RenameOption {
+	return func(config *renameConfig) {
+		config.checkedFiles = files
+	}
+}
+
+// Rename returns the edits which rename every definition of and reference to name, including the
+// inactive ones. It fails if another entry is already called newName since the engine would merge
+// both entries or complain about a duplicate.
+func (i *Index) Rename(kind Kind, name, newName string, opts ...RenameOption) ([]Edit, error) {
+	config := renameConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if newName == "" || strings.TrimSpace(newName) != newName || strings.ContainsAny(newName, "\";\r\n") ||
+		strings.Contains(newName, "/*") || strings.Contains(newName, "!*") {
+		// Quotes, line breaks and comment markers would break the tables
+		return nil, eris.Errorf("Invalid name \"%s\"", newName)
+	}
+
+	if len(newName) > maxNameLength {
+		return nil, eris.Errorf("\"%s\" is longer than %d characters", newName, maxNameLength)
+	}
+
+	if !strings.EqualFold(name, newName) {
+		if existing := i.filter(kind, newName, true, true); len(existing) > 0 {
+			return nil, eris.Errorf("%s \"%s\" already exists in %s:%d", kind, newName, existing[0].File, existing[0].Range[0])
+		}
+	}
+
+	symbols := append(i.filter(kind, name, true, true), i.filter(kind, name, false, true)...)
+	if len(symbols) == 0 {
+		return nil, eris.Errorf("Unknown %s \"%s\"", kind, name)
+	}
+
+	edits := make([]Edit, len(symbols))
+	for idx, symbol := range symbols {
+		edits[idx] = Edit{File: symbol.File, Range: symbol.Range, NewText: newName}
+	}
+
+	if uses := unindexedUses(config.checkedFiles, edits, name); len(uses) > 0 {
+		return nil, eris.Errorf("\"%s\" is also used in places rename can't update, e.g. SEXP arguments. "+
+			"Rename them by hand first:\n  %s", name, strings.Join(uses, "\n  "))
+	}
+
+	return edits, nil
+}
+
+// unindexedUses returns the file:line of every quoted name in files which isn't covered by one of
+// the edits.
+func unindexedUses(files map[string]string, edits []Edit, name string) []string {
+	covered := make(map[string]bool)
+	for _, edit := range edits {
+		covered[fmt.Sprintf("%s:%d:%d", edit.File, edit.Range[0], edit.Range[1])] = true
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	uses := make([]string, 0)
+	for _, path := range paths {
+		for idx, line := range strings.Split(files[path], "\n") {
+			for pos := 0; pos+len(name)+1 < len(line); pos++ {
+				end := pos + 1 + len(name)
+				if line[pos] != '"' || line[end] != '"' || !strings.EqualFold(line[pos+1:end], name) {
+					continue
+				}
+
+				col := utf8.RuneCountInString(line[:pos+1])
+				if !covered[fmt.Sprintf("%s:%d:%d", path, idx+1, col)] {
+					uses = append(uses, fmt.Sprintf("%s:%d", path, idx+1))
+				}
+			}
+		}
+	}
+
+	return uses
+}
+
+// ApplyEdits applies the edits for a single file to its content. The edits must not overlap.
+func ApplyEdits(content string, edits []Edit) string {
+	sorted := make([]Edit, len(edits))
+	copy(sorted, edits)
+	// Apply the edits from the end of the file to keep the positions of earlier ones valid
+	sort.Slice(sorted, func(a, b int) bool {
+		if sorted[a].Range[0] != sorted[b].Range[0] {
+			return sorted[a].Range[0] > sorted[b].Range[0]
+		}
+		return sorted[a].Range[1] > sorted[b].Range[1]
+	})
+
+	lines := strings.Split(content, "\n")
+	for _, edit := range sorted {
+		start, end := edit.Range[0]-1, edit.Range[2]-1
+		if start < 0 || end >= len(lines) {
+			continue
+		}
+
+		first := []rune(lines[start])
+		last := []rune(lines[end])
+		if edit.Range[1] > len(first) || edit.Range[3] > len(last) {
+			continue
+		}
+
+		replaced := string(first[:edit.Range[1]]) + edit.NewText + string(last[edit.Range[3]:])
+		lines = append(lines[:start], append([]string{replaced}, lines[end+1:]...)...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unresolved returns every active reference without a matching active definition. Kinds without
+// any loaded definitions are skipped since the table defining them is probably just not part of
+// the index.
+func (i *Index) Unresolved() []Symbol {
+	defined := make(map[Kind]map[string]bool)
+	for kind, names := range builtins {
+		defined[kind] = make(map[string]bool)
+		for _, name := range names {
+			defined[kind][strings.ToLower(name)] = true
+		}
+	}
+
+	for _, symbol := range i.symbols {
+		if !symbol.Definition || symbol.Inactive {
+			continue
+		}
+
+		if defined[symbol.Kind] == nil {
+			defined[symbol.Kind] = make(map[string]bool)
+		}
+		defined[symbol.Kind][strings.ToLower(symbol.Name)] = true
+	}
+
+	result := make([]Symbol, 0)
+	for _, symbol := range i.symbols {
+		names := defined[symbol.Kind]
+		if symbol.Definition || symbol.Inactive || names == nil || names[strings.ToLower(symbol.Name)] {
+			continue
+		}
+
+		result = append(result, symbol)
+	}
+
+	return result
+}
+
+// Check returns a ParserError for every unresolved reference, grouped by file.
+func (i *Index) Check() map[string][]error {
+	result := make(map[string][]error)
+	for _, symbol := range i.Unresolved() {
+		err := parser.NewParserError(fmt.Sprintf("Unknown %s \"%s\"", symbol.Kind, symbol.Name), symbol.Range)
+		result[symbol.File] = append(result[symbol.File], eris.Wrap(err, ""))
+	}
+
+	return result
+}
+
+func matchPath(path, pattern []string) bool {
+	if len(pattern) == 0 || len(pattern) > len(path) {
+		return false
+	}
+
+	offset := len(path) - len(pattern)
+	for idx, label := range pattern {
+		if !strings.EqualFold(strings.TrimSuffix(path[offset+idx], ":"), strings.TrimSuffix(label, ":")) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// valueNames returns the names contained in a value. Lists return one name per item.
+func valueNames(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case [][]string:
+		result := make([]string, 0)
+		for _, bank := range value {
+			result = append(result, bank...)
+		}
+		return result
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			result = append(result, valueNames(item)...)
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// findRanges locates each name inside valueRange. The names have to appear in order. Names that
+// can't be found get an empty range.
+func findRanges(lines []string, valueRange [4]int, names []string) [][4]int {
+	result := make([][4]int, len(names))
+	line := valueRange[0] - 1
+	col := valueRange[1]
+	for idx, name := range names {
+		needle := []rune(name)
+		if len(needle) == 0 {
+			continue
+		}
+
+		for searchLine, searchCol := line, col; searchLine < len(lines) && searchLine < valueRange[2]; searchLine, searchCol = searchLine+1, 0 {
+			text := []rune(lines[searchLine])
+			pos := indexRunes(text, needle, searchCol)
+			if pos == -1 {
+				continue
+			}
+
+			result[idx] = [4]int{searchLine + 1, pos, searchLine + 1, pos + len(needle)}
+			line = searchLine
+			col = pos + len(needle)
+			break
+		}
+	}
+
+	return result
+}
+
+func indexRunes(text, needle []rune, start int) int {
+	for pos := start; pos+len(needle) <= len(text); pos++ {
+		if string(text[pos:pos+len(needle)]) == string(needle) {
+			return pos
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,178 @@
+package index
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestUnresolved(t *testing.T) {
+	rules := []Rule{
+		{Path: []string{"#Weapons", "$Name"}, Kind: Weapon, Definition: true},
+		{Path: []string{"$Default PBanks"}, Kind: Weapon},
+		{Path: []string{"$Armor Type"}, Kind: ArmorType},
+		{Path: []string{"$Species"}, Kind: Species},
+	}
+
+	const table = `#Weapons
+$Name: @Subach HL-7
+$Name: Akheton SDG
+#End
+
+#Ships
+$Name: GTF Ulysses
+$Species: Terran
+$Default PBanks: ( "Subach HL-7" "Akheton SGD" )
+$Armor Type: Light
+$Name: GTF Hercules
+$Species: Terrran
+#End
+`
+
+	nodes, err := parser.ParseGeneric(context.Background(), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := New()
+	idx.Add("test.tbl", table, nodes, rules)
+
+	if defs := idx.Definitions(Weapon, "subach hl-7"); len(defs) != 1 || defs[0].Range != [4]int{2, 8, 2, 19} {
+		t.Errorf("unexpected definitions %+v", defs)
+	}
+
+	unresolved := idx.Unresolved()
+	if len(unresolved) != 2 {
+		t.Fatalf("expected two unresolved references but got %+v", unresolved)
+	}
+
+	// Armor types are skipped since none are defined.
+	if unresolved[0].Name != "Akheton SGD" || unresolved[0].Range != [4]int{9, 34, 9, 45} {
+		t.Errorf("unexpected symbol %+v", unresolved[0])
+	}
+
+	if unresolved[1].Kind != Species || unresolved[1].Name != "Terrran" {
+		t.Errorf("unexpected symbol %+v", unresolved[1])
+	}
+}
+
+func TestRename(t *testing.T) {
+	rules := []Rule{
+		{Path: []string{"#Weapons", "$Name"}, Kind: Weapon, Definition: true},
+		{Path: []string{"$Default PBanks"}, Kind: Weapon},
+	}
+
+	const table = `#Weapons
+$Name: @Subach HL-7
+$Name: Akheton SDG
+#End
+
+#Ships
+$Name: GTF Ulysses
+$Default PBanks: ( "Subach HL-7" "Akheton SDG" "Subach HL-7" )
+#End
+`
+
+	nodes, err := parser.ParseGeneric(context.Background(), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := New()
+	idx.Add("test.tbl", table, nodes, rules)
+
+	if _, err := idx.Rename(Weapon, "Subach HL-7", "akheton sdg"); err == nil {
+		t.Error("expected an error for an existing name")
+	}
+
+	invalid := []string{
+		"", " Subach", "Subach \"HL\"", "Subach; HL", "Subach /* HL */", "Subach !* HL *!",
+		"Subach HL-7 with a very long name",
+	}
+	for _, name := range invalid {
+		if _, err := idx.Rename(Weapon, "Subach HL-7", name); err == nil {
+			t.Errorf("expected an error for the invalid name %q", name)
+		}
+	}
+
+	edits, err := idx.Rename(Weapon, "subach hl-7", "Subach HL-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `#Weapons
+$Name: @Subach HL-9
+$Name: Akheton SDG
+#End
+
+#Ships
+$Name: GTF Ulysses
+$Default PBanks: ( "Subach HL-9" "Akheton SDG" "Subach HL-9" )
+#End
+`
+	if result := ApplyEdits(table, edits); result != expected {
+		t.Errorf("unexpected result:\n%s", result)
+	}
+
+	mission := "$Formula: ( has-primary-weapon \"Alpha 1\" 0 \"Subach HL-7\" )\n"
+	_, err = idx.Rename(Weapon, "Subach HL-7", "Subach HL-9", RefuseUnindexedUses(map[string]string{"test.fs2": mission}))
+	if err == nil || !strings.Contains(err.Error(), "test.fs2:1") {
+		t.Errorf("expected an error for the SEXP argument but got %v", err)
+	}
+}
+
+func TestFindRanges(t *testing.T) {
+	lines := []string{`$Default PBanks: ( "Subach HL-7" "Akheton SDG" )`}
+	ranges := findRanges(lines, [4]int{1, 17, 1, 49}, []string{"Subach HL-7", "Missing", "Akheton SDG"})
+
+	expected := [][4]int{{1, 20, 1, 31}, {}, {1, 34, 1, 45}}
+	for idx, r := range ranges {
+		if r != expected[idx] {
+			t.Errorf("unexpected range %v for item %d", r, idx)
+		}
+	}
+}
+
+func TestInactiveSymbols(t *testing.T) {
+	rules := []Rule{
+		{Path: []string{"#Weapons", "$Name"}, Kind: Weapon, Definition: true},
+		{Path: []string{"$Name", "+Use Template"}, Kind: Weapon},
+	}
+
+	const table = `#Weapons
+$Name: Subach HL-7
+$Name: Subach HL-8
+;;!FSO 3.8.0;; +Use Template: Subach HL-7
+#End
+`
+
+	parse := func(version parser.Version) ([]*parser.Node, []parser.VersionRegion) {
+		_, regions, _ := parser.ApplyVersionComments(table, version)
+		nodes, err := parser.ParseGeneric(context.Background(), table, parser.WithEngineVersion(version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nodes, regions
+	}
+
+	idx := New()
+	nodes, regions := parse(parser.LatestVersion)
+	idx.Add("test.tbl", table, nodes, rules)
+
+	oldNodes, _ := parse(parser.Version{})
+	idx.AddSymbols(InactiveSymbols("test.tbl", table, oldNodes, rules, regions))
+
+	if refs := idx.References(Weapon, "Subach HL-7"); len(refs) != 0 {
+		t.Errorf("inactive references must be ignored but got %+v", refs)
+	}
+
+	edits, err := idx.Rename(Weapon, "Subach HL-7", "Subach HL-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 2 || edits[1].Range != [4]int{4, 30, 4, 41} {
+		t.Errorf("unexpected edits %+v", edits)
+	}
+}
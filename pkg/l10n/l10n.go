@@ -0,0 +1,363 @@
+// Package l10n collects the translatable strings (XSTR) of a set of parsed tables and missions and
+// compares them with the translations in tstrings.tbl and strings.tbl.
+package l10n
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/rotisserie/eris"
+)
+
+// String is an XSTR found in a table.
+type String struct {
+	ID    int
+	Text  string
+	File  string
+	Range [4]int
+}
+
+// Translation is a single entry of a translation table.
+type Translation struct {
+	Language string
+	ID       int
+	Text     string
+	File     string
+	Range    [4]int
+}
+
+// Catalog stores the strings and translations of all added files.
+type Catalog struct {
+	strings      []String
+	translations []Translation
+}
+
+func New() *Catalog {
+	return &Catalog{
+		strings:      make([]String, 0),
+		translations: make([]Translation, 0),
+	}
+}
+
+// IsTranslationTable returns true for the tables containing translations: tstrings.tbl,
+// strings.tbl and their modular tables (*-tlc.tbm and *-lcl.tbm).
+func IsTranslationTable(filename string) bool {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(filename, "\\", "/")))
+	return name == "tstrings.tbl" || name == "strings.tbl" || strings.HasSuffix(name, "-tlc.tbm") ||
+		strings.HasSuffix(name, "-lcl.tbm")
+}
+
+// isEngineTable returns true for strings.tbl and its modular tables. Their entries are used by
+// the engine itself instead of the tables.
+func isEngineTable(filename string) bool {
+	name := strings.ToLower(path.Base(strings.ReplaceAll(filename, "\\", "/")))
+	return name == "strings.tbl" || strings.HasSuffix(name, "-lcl.tbm")
+}
+
+// Add collects the XSTR values in nodes.
+func (c *Catalog) Add(file string, nodes []*parser.Node) {
+	for _, node := range nodes {
+		if value, ok := node.Value.(parser.XSTR); ok {
+			c.strings = append(c.strings, String{
+				ID:    value.ID,
+				Text:  value.Text,
+				File:  file,
+				Range: node.ValueRange,
+			})
+		}
+
+		c.Add(file, node.Children)
+	}
+}
+
+// AddTranslations parses the translation table content. Each #Language section contains entries
+// like `3024, "text"`; anything following the closing quote is ignored.
+func (c *Catalog) AddTranslations(file, content string) error {
+	translations, err := parseTranslations(file, content)
+	if err != nil {
+		return err
+	}
+
+	c.translations = append(c.translations, translations...)
+	return nil
+}
+
+// Remove drops all strings and translations collected from file.
+func (c *Catalog) Remove(file string) {
+	strs := c.strings[:0]
+	for _, str := range c.strings {
+		if str.File != file {
+			strs = append(strs, str)
+		}
+	}
+	c.strings = strs
+
+	translations := c.translations[:0]
+	for _, translation := range c.translations {
+		if translation.File != file {
+			translations = append(translations, translation)
+		}
+	}
+	c.translations = translations
+}
+
+// Strings returns all collected strings in the order they were added.
+func (c *Catalog) Strings() []String {
+	return c.strings
+}
+
+// Translations returns all translations for language.
+func (c *Catalog) Translations(language string) []Translation {
+	result := make([]Translation, 0)
+	for _, translation := range c.translations {
+		if strings.EqualFold(translation.Language, language) {
+			result = append(result, translation)
+		}
+	}
+
+	return result
+}
+
+// Languages returns the languages found in the translation tables in the order they first
+// appeared.
+func (c *Catalog) Languages() []string {
+	result := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, translation := range c.translations {
+		key := strings.ToLower(translation.Language)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, translation.Language)
+		}
+	}
+
+	return result
+}
+
+// Conflicts returns the strings which use an ID that an earlier string uses with a different text.
+// The first string with each ID is considered to be the correct one.
+func (c *Catalog) Conflicts() [][2]String {
+	first := make(map[int]String)
+	result := make([][2]String, 0)
+	for _, str := range c.strings {
+		if str.ID < 0 {
+			continue
+		}
+
+		original, ok := first[str.ID]
+		if !ok {
+			first[str.ID] = str
+			continue
+		}
+
+		if original.Text != str.Text {
+			result = append(result, [2]String{original, str})
+		}
+	}
+
+	return result
+}
+
+// Missing returns the first string for every ID that doesn't have a translation for language.
+// Strings with ID -1 can't be translated and are skipped.
+func (c *Catalog) Missing(language string) []String {
+	translated := make(map[int]bool)
+	for _, translation := range c.Translations(language) {
+		translated[translation.ID] = true
+	}
+
+	result := make([]String, 0)
+	for _, str := range c.strings {
+		if str.ID < 0 || translated[str.ID] {
+			continue
+		}
+
+		translated[str.ID] = true
+		result = append(result, str)
+	}
+
+	return result
+}
+
+// Stale returns the translations for language whose ID isn't used by any string. Entries from
+// strings.tbl are skipped since the engine uses them for its own texts.
+func (c *Catalog) Stale(language string) []Translation {
+	used := make(map[int]bool)
+	for _, str := range c.strings {
+		used[str.ID] = true
+	}
+
+	result := make([]Translation, 0)
+	for _, translation := range c.Translations(language) {
+		if !used[translation.ID] && !isEngineTable(translation.File) {
+			result = append(result, translation)
+		}
+	}
+
+	return result
+}
+
+// Check returns a ParserError for every conflicting ID, missing and stale translation, grouped by
+// file.
+func (c *Catalog) Check() map[string][]error {
+	result := make(map[string][]error)
+	report := func(file, msg string, location [4]int) {
+		result[file] = append(result[file], eris.Wrap(parser.NewParserError(msg, location), ""))
+	}
+
+	for _, conflict := range c.Conflicts() {
+		original, str := conflict[0], conflict[1]
+		report(str.File, fmt.Sprintf("XSTR ID %d is used for \"%s\" but %s:%d uses it for \"%s\"", str.ID, str.Text,
+			original.File, original.Range[0], original.Text), str.Range)
+	}
+
+	languages := c.Languages()
+	sort.Strings(languages)
+	for _, language := range languages {
+		for _, str := range c.Missing(language) {
+			report(str.File, fmt.Sprintf("Missing %s translation for XSTR ID %d (\"%s\")", language, str.ID, str.Text), str.Range)
+		}
+
+		for _, translation := range c.Stale(language) {
+			report(translation.File, fmt.Sprintf("%s translation %d isn't used by any XSTR", language, translation.ID), translation.Range)
+		}
+	}
+
+	return result
+}
+
+// translationScanner walks through a translation table while tracking line and column the same
+// way the parser does (lines start at 1, columns at 0).
+type translationScanner struct {
+	content []rune
+	pos     int
+	line    int
+	col     int
+}
+
+func (s *translationScanner) eof() bool {
+	return s.pos >= len(s.content)
+}
+
+func (s *translationScanner) peek() rune {
+	return s.content[s.pos]
+}
+
+func (s *translationScanner) advance() {
+	if s.content[s.pos] == '\n' {
+		s.line++
+		s.col = 0
+	} else {
+		s.col++
+	}
+	s.pos++
+}
+
+func (s *translationScanner) skipLine() {
+	for !s.eof() && s.peek() != '\n' {
+		s.advance()
+	}
+}
+
+func (s *translationScanner) skipBlanks() {
+	for !s.eof() && (s.peek() == ' ' || s.peek() == '\t' || s.peek() == '\r') {
+		s.advance()
+	}
+}
+
+func (s *translationScanner) skipWhitespace() {
+	for !s.eof() && strings.ContainsRune(" \t\r\n", s.peek()) {
+		s.advance()
+	}
+}
+
+func (s *translationScanner) location() [4]int {
+	return [4]int{s.line, s.col, s.line, s.col}
+}
+
+func parseTranslations(file, content string) ([]Translation, error) {
+	s := &translationScanner{content: []rune(content), line: 1}
+	result := make([]Translation, 0)
+	language := ""
+
+	for {
+		s.skipWhitespace()
+		if s.eof() {
+			return result, nil
+		}
+
+		start := s.location()
+		char := s.peek()
+		switch {
+		case char == ';':
+			s.skipLine()
+		case char == '#':
+			begin := s.pos
+			s.skipLine()
+			name := strings.TrimSpace(string(s.content[begin+1 : s.pos]))
+			if idx := strings.IndexByte(name, ';'); idx != -1 {
+				name = strings.TrimSpace(name[:idx])
+			}
+
+			if strings.EqualFold(name, "end") {
+				language = ""
+			} else {
+				language = name
+			}
+		case char == '-' || (char >= '0' && char <= '9'):
+			begin := s.pos
+			s.advance()
+			for !s.eof() && s.peek() >= '0' && s.peek() <= '9' {
+				s.advance()
+			}
+
+			var id int
+			if _, err := fmt.Sscan(string(s.content[begin:s.pos]), &id); err != nil {
+				return nil, eris.Wrapf(parser.NewParserError("Invalid translation ID", [4]int{start[0], start[1], s.line, s.col}), "failed to parse %s", file)
+			}
+
+			if language == "" {
+				return nil, eris.Wrapf(parser.NewParserError("Translation outside of a language section", start), "failed to parse %s", file)
+			}
+
+			s.skipBlanks()
+			if s.eof() || s.peek() != ',' {
+				return nil, eris.Wrapf(parser.NewParserError("Expected ',' after the translation ID", s.location()), "failed to parse %s", file)
+			}
+			s.advance()
+
+			s.skipWhitespace()
+			if s.eof() || s.peek() != '"' {
+				return nil, eris.Wrapf(parser.NewParserError("Expected a quoted translation", s.location()), "failed to parse %s", file)
+			}
+			s.advance()
+
+			begin = s.pos
+			for !s.eof() && s.peek() != '"' {
+				s.advance()
+			}
+			if s.eof() {
+				return nil, eris.Wrapf(parser.NewParserError("Missing closing quote", start), "failed to parse %s", file)
+			}
+
+			text := string(s.content[begin:s.pos])
+			s.advance()
+			result = append(result, Translation{
+				Language: language,
+				ID:       id,
+				Text:     text,
+				File:     file,
+				Range:    [4]int{start[0], start[1], s.line, s.col},
+			})
+
+			// Some entries in strings.tbl are followed by offsets
+			s.skipLine()
+		default:
+			// Other sections like #Supported Languages use labels instead of numbered entries
+			s.skipLine()
+		}
+	}
+}
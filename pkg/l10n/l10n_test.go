@@ -0,0 +1,92 @@
+package l10n
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestCheck(t *testing.T) {
+	const table = `#Ship Classes
+$Name: GTF Ulysses
+$Alt Name: XSTR("Ulysses", 3024)
++Tech Title: XSTR("Untranslated", -1)
+$Name: GTF Hercules
+$Alt Name: XSTR("Hercules", 3024)
+$Name: GTF Myrmidon
+$Alt Name: XSTR("Myrmidon", 3026)
+#End
+`
+
+	const translations = `; Comment
+#German
+3024, "Ulysses"
+3025, "Veraltet; alt" ; stale
+#End
+
+#French
+3024, "Ulysse"
+3026, "Myrmidon
+sur deux lignes"
+#End
+`
+
+	nodes, err := parser.ParseGeneric(context.Background(), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catalog := New()
+	catalog.Add("ships.tbl", nodes)
+	if err := catalog.AddTranslations("tstrings.tbl", translations); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(catalog.Strings()) != 4 {
+		t.Errorf("expected 4 strings but got %v", catalog.Strings())
+	}
+
+	french := catalog.Translations("french")
+	if len(french) != 2 || french[1].Text != "Myrmidon\nsur deux lignes" || french[1].Range != [4]int{9, 0, 10, 16} {
+		t.Errorf("unexpected French translations %#v", french)
+	}
+
+	conflicts := catalog.Conflicts()
+	if len(conflicts) != 1 || conflicts[0][1].Text != "Hercules" {
+		t.Errorf("unexpected conflicts %#v", conflicts)
+	}
+
+	missing := catalog.Missing("German")
+	if len(missing) != 1 || missing[0].ID != 3026 {
+		t.Errorf("unexpected missing translations %#v", missing)
+	}
+
+	stale := catalog.Stale("German")
+	if len(stale) != 1 || stale[0].ID != 3025 || stale[0].Text != "Veraltet; alt" {
+		t.Errorf("unexpected stale translations %#v", stale)
+	}
+
+	problems := catalog.Check()
+	if len(problems["ships.tbl"]) != 2 || len(problems["tstrings.tbl"]) != 1 {
+		t.Errorf("unexpected problems %v", problems)
+	}
+}
+
+func TestTranslationErrors(t *testing.T) {
+	tests := map[string]string{
+		"3024, \"text\"\n":                 "Translation outside of a language section",
+		"#German\n3024 \"text\"\n":         "Expected ','",
+		"#German\n3024, text\n":            "Expected a quoted translation",
+		"#German\n3024, \"text\n#End\n":    "Missing closing quote",
+		"#German\n3024, \"a\"\n-, \"b\"\n": "Invalid translation ID",
+	}
+
+	for content, expected := range tests {
+		err := New().AddTranslations("tstrings.tbl", content)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%q: expected %q but got %v", content, expected, err)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Package loader finds the tables and missions of a FreeSpace installation and its mods in the
+// order the engine loads them.
+package loader
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/merge"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+	"github.com/ngld/fso-table-parser/pkg/vp"
+	"github.com/rotisserie/eris"
+)
+
+// Source is a folder the engine searches for game data: a mod folder or the game root.
+type Source struct {
+	// Mod is the mod's folder name or an empty string for the game root.
+	Mod string
+	// FS contains the source's files.
+	FS fs.FS
+	// Archives contains the VP files in the source's folder in the order they're searched.
+	Archives []string
+	// tables maps lower case file names to the highest priority file in this source.
+	tables map[string]File
+	// missions works like tables for the files in data/missions.
+	missions map[string]File
+}
+
+// File is a table or mission inside a Source.
+type File struct {
+	// Name is the file name (e.g. ships.tbl).
+	Name string
+	// Path is the file's path inside FS.
+	Path string
+	// Archive is the name of the VP file containing the file or empty for loose files.
+	Archive string
+	// FS is either the source's FS or the archive's.
+	FS     fs.FS
+	Source *Source
+}
+
+// Read returns the file's content.
+func (f File) Read() ([]byte, error) {
+	data, err := fs.ReadFile(f.FS, f.Path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read %s", f.String())
+	}
+
+	return data, nil
+}
+
+// String returns a human readable location like "mediavps/data/tables/ships.tbl" or
+// "mediavps/mv_core.vp/data/tables/ships.tbl".
+func (f File) String() string {
+	result := f.Path
+	if f.Archive != "" {
+		result = f.Archive + "/" + result
+	}
+
+	if f.Source.Mod != "" {
+		result = f.Source.Mod + "/" + result
+	}
+
+	return result
+}
+
+// Loader provides access to the tables and missions of a set of mods.
+type Loader struct {
+	sources  []*Source
+	archives []*vp.Archive
+}
+
+// New resolves mods (like the engine's -mod option) inside root. Dependencies listed in each
+// mod's mod.ini are added the same way the launchers do: the primarylist before the mod and the
+// secondarylist after it. The game root always has the lowest priority.
+func New(root fs.FS, mods []string) (*Loader, error) {
+	resolved, err := ResolveMods(root, mods)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := &Loader{}
+	for _, mod := range resolved {
+		sub, err := fs.Sub(root, mod)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to open mod %s", mod)
+		}
+
+		loader.sources = append(loader.sources, &Source{Mod: mod, FS: sub})
+	}
+	loader.sources = append(loader.sources, &Source{FS: root})
+
+	for _, source := range loader.sources {
+		if err := loader.scan(source); err != nil {
+			loader.Close()
+			return nil, err
+		}
+	}
+
+	return loader, nil
+}
+
+// Close closes all opened VP archives.
+func (l *Loader) Close() error {
+	var result error
+	for _, archive := range l.archives {
+		if err := archive.Close(); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	l.archives = nil
+	return result
+}
+
+// Sources returns the search paths from highest to lowest priority.
+func (l *Loader) Sources() []*Source {
+	return l.sources
+}
+
+// Find returns the highest priority copy of the named table.
+func (l *Loader) Find(name string) (File, bool) {
+	name = strings.ToLower(name)
+	for _, source := range l.sources {
+		if file, ok := source.tables[name]; ok {
+			return file, true
+		}
+	}
+
+	return File{}, false
+}
+
+// Table returns the files the engine reads for def in load order: the base table followed by
+// the modular tables. If several sources contain a file with the same name, only the one with the
+// highest priority is used.
+func (l *Loader) Table(def structs.TableDefinition) []File {
+	result := make([]File, 0)
+	if base, ok := l.Find(def.Name); ok {
+		result = append(result, base)
+	}
+
+	if def.ModularSuffix == "" {
+		return result
+	}
+
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, source := range l.sources {
+		for name := range source.tables {
+			if strings.HasSuffix(name, def.ModularSuffix) && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	merge.SortModularTables(names)
+	for _, name := range names {
+		file, _ := l.Find(name)
+		result = append(result, file)
+	}
+
+	return result
+}
+
+// Files returns every table in load order. Known tables are listed in the registry's order,
+// followed by unknown tables sorted by name.
+func (l *Loader) Files() []File {
+	result := make([]File, 0)
+	seen := make(map[string]bool)
+	for _, def := range structs.Tables() {
+		for _, file := range l.Table(def) {
+			seen[strings.ToLower(file.Name)] = true
+			result = append(result, file)
+		}
+	}
+
+	rest := make([]string, 0)
+	for _, source := range l.sources {
+		for name := range source.tables {
+			if !seen[name] {
+				seen[name] = true
+				rest = append(rest, name)
+			}
+		}
+	}
+
+	sort.Strings(rest)
+	for _, name := range rest {
+		file, _ := l.Find(name)
+		result = append(result, file)
+	}
+
+	return result
+}
+
+// Missions returns the highest priority copy of every mission sorted by name.
+func (l *Loader) Missions() []File {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, source := range l.sources {
+		for name := range source.missions {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	result := make([]File, len(names))
+	for idx, name := range names {
+		for _, source := range l.sources {
+			if file, ok := source.missions[name]; ok {
+				result[idx] = file
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// scan collects the tables and missions in the source's data folder and its VP files. Loose
+// files take priority over archives; archives are searched in alphabetical order.
+func (l *Loader) scan(source *Source) error {
+	source.tables = make(map[string]File)
+	source.missions = make(map[string]File)
+	if err := addFiles(source, source.FS, ""); err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(source.FS, ".")
+	if err != nil {
+		return eris.Wrap(err, "failed to list source")
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".vp") {
+			continue
+		}
+
+		archive, err := vp.Open(source.FS, entry.Name())
+		if err != nil {
+			return err
+		}
+
+		l.archives = append(l.archives, archive)
+		source.Archives = append(source.Archives, entry.Name())
+		if err := addFiles(source, archive, entry.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addFiles adds the tables and missions from fsys that the source doesn't contain yet.
+func addFiles(source *Source, fsys fs.FS, archive string) error {
+	if err := addFolder(source.tables, source, fsys, archive, "data/tables", ".tbl", ".tbm"); err != nil {
+		return err
+	}
+
+	return addFolder(source.missions, source, fsys, archive, "data/missions", ".fs2")
+}
+
+// addFolder adds the files from folder ending in one of extensions to files unless they're
+// already present.
+func addFolder(files map[string]File, source *Source, fsys fs.FS, archive, folder string, extensions ...string) error {
+	dir, found, err := findPath(fsys, folder)
+	if err != nil || !found {
+		return err
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return eris.Wrapf(err, "failed to list %s", dir)
+	}
+
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if entry.IsDir() || !hasExtension(name, extensions) {
+			continue
+		}
+
+		if _, exists := files[name]; !exists {
+			files[name] = File{
+				Name:    entry.Name(),
+				Path:    path.Join(dir, entry.Name()),
+				Archive: archive,
+				FS:      fsys,
+				Source:  source,
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasExtension(name string, extensions []string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findPath resolves a slash separated path while ignoring the case of each element since mods
+// aren't consistent about it (data/Tables, Data/tables, ...).
+func findPath(fsys fs.FS, name string) (string, bool, error) {
+	current := "."
+	for _, part := range strings.Split(name, "/") {
+		entries, err := fs.ReadDir(fsys, current)
+		if err != nil {
+			return "", false, eris.Wrapf(err, "failed to list %s", current)
+		}
+
+		found := false
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), part) {
+				current = path.Join(current, entry.Name())
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return "", false, nil
+		}
+	}
+
+	return current, true, nil
+}
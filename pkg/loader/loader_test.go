@@ -0,0 +1,109 @@
+package loader
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ngld/fso-table-parser/pkg/structs"
+	"github.com/ngld/fso-table-parser/pkg/vp"
+)
+
+func TestLoadOrder(t *testing.T) {
+	root := fstest.MapFS{
+		"data/tables/ships.tbl":             {Data: []byte("root")},
+		"data/tables/zz-shp.tbm":            {Data: []byte("root")},
+		"ModA/mod.ini":                      {Data: []byte("[multimod]\nprimarylist = modb;\nsecondarylist = mediavps, modb;\n")},
+		"ModA/Data/Tables/aa-shp.tbm":       {Data: []byte("a")},
+		"modb/data/tables/ships.tbl":        {Data: []byte("b")},
+		"modb/data/tables/bb-shp.tbm":       {Data: []byte("b")},
+		"mediavps/data/tables/aa-shp.tbm":   {Data: []byte("mediavps")},
+		"mediavps/data/tables/weapons.tbl":  {Data: []byte("mediavps")},
+		"mediavps/data/tables/unknown.tbm":  {Data: []byte("mediavps")},
+		"unrelated/data/tables/weapons.tbl": {Data: []byte("unrelated")},
+		"data/missions/sm1-01.fs2":          {Data: []byte("root")},
+		"mediavps/data/missions/SM1-01.fs2": {Data: []byte("mediavps")},
+		"modb/data/missions/custom.fs2":     {Data: []byte("b")},
+		"modb/data/missions/notes.txt":      {Data: []byte("b")},
+	}
+
+	mods, err := ResolveMods(root, []string{"moda"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"modb", "ModA", "mediavps"}; !reflect.DeepEqual(mods, expected) {
+		t.Errorf("expected %v but got %v", expected, mods)
+	}
+
+	loader, err := New(root, []string{"moda"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ships, _ := structs.LookupTable("ships.tbl")
+	files := make([]string, 0)
+	for _, file := range loader.Table(ships) {
+		files = append(files, file.String())
+	}
+
+	expected := []string{
+		"modb/data/tables/ships.tbl",
+		"data/tables/zz-shp.tbm",
+		"modb/data/tables/bb-shp.tbm",
+		"ModA/Data/Tables/aa-shp.tbm",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected %v but got %v", expected, files)
+	}
+
+	all := loader.Files()
+	if last := all[len(all)-1].String(); last != "mediavps/data/tables/unknown.tbm" {
+		t.Errorf("expected unknown tables at the end but got %s", last)
+	}
+
+	missions := make([]string, 0)
+	for _, file := range loader.Missions() {
+		missions = append(missions, file.String())
+	}
+
+	expected = []string{"modb/data/missions/custom.fs2", "mediavps/data/missions/SM1-01.fs2"}
+	if !reflect.DeepEqual(missions, expected) {
+		t.Errorf("expected %v but got %v", expected, missions)
+	}
+
+	var archive bytes.Buffer
+	err = vp.Write(&archive, fstest.MapFS{
+		"data/tables/weapons.tbl": {Data: []byte("vp")},
+		"data/tables/cc-shp.tbm":  {Data: []byte("vp")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root["mediavps/MV_Core.vp"] = &fstest.MapFile{Data: archive.Bytes()}
+	loader, err = New(root, []string{"moda"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+
+	weapons, found := loader.Find("weapons.tbl")
+	if !found || weapons.String() != "mediavps/data/tables/weapons.tbl" {
+		t.Errorf("loose files should take priority over archives but got %s", weapons)
+	}
+
+	modular, found := loader.Find("cc-shp.tbm")
+	if !found || modular.String() != "mediavps/MV_Core.vp/data/tables/cc-shp.tbm" {
+		t.Fatalf("unexpected file %s", modular)
+	}
+
+	if data, err := modular.Read(); err != nil || string(data) != "vp" {
+		t.Errorf("unexpected content %q (%v)", data, err)
+	}
+
+	if _, err := New(root, []string{"missing"}); err == nil {
+		t.Error("expected an error for a missing mod")
+	}
+}
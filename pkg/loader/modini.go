@@ -0,0 +1,139 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// ModIni contains the dependency lists from a mod's mod.ini.
+type ModIni struct {
+	// PrimaryList contains mods which take priority over this mod.
+	PrimaryList []string
+	// SecondaryList contains mods this mod overrides.
+	SecondaryList []string
+}
+
+// ParseModIni reads the [multimod] section of a mod.ini file. Other sections are ignored.
+func ParseModIni(data []byte) ModIni {
+	result := ModIni{}
+	section := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+
+		if section != "multimod" {
+			continue
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq == -1 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(line[:eq]))
+		switch key {
+		case "primarylist":
+			result.PrimaryList = splitModList(line[eq+1:])
+		case "secondarylist":
+			result.SecondaryList = splitModList(line[eq+1:])
+		}
+	}
+
+	return result
+}
+
+// splitModList parses values like "mod1, mod2;" into a list of mod folders.
+func splitModList(value string) []string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimSuffix(value, ";")
+	value = strings.Trim(value, "\"")
+
+	result := make([]string, 0)
+	for _, mod := range strings.Split(value, ",") {
+		mod = strings.TrimSpace(mod)
+		if mod != "" {
+			result = append(result, mod)
+		}
+	}
+
+	return result
+}
+
+// ResolveMods expands mods with the dependencies from their mod.ini files and returns the mod
+// folders inside root from highest to lowest priority. Each mod only appears once, at its highest
+// priority.
+func ResolveMods(root fs.FS, mods []string) ([]string, error) {
+	result := make([]string, 0)
+	visited := make(map[string]bool)
+
+	var expand func(mod string) error
+	expand = func(mod string) error {
+		folder, found, err := findPath(root, strings.Trim(path.Clean(strings.ReplaceAll(mod, "\\", "/")), "/"))
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return eris.Errorf("mod %s not found", mod)
+		}
+
+		key := strings.ToLower(folder)
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+
+		ini := ModIni{}
+		iniPath, found, err := findPath(root, folder+"/mod.ini")
+		if err != nil {
+			return err
+		}
+
+		if found {
+			data, err := fs.ReadFile(root, iniPath)
+			if err != nil {
+				return eris.Wrapf(err, "failed to read %s", iniPath)
+			}
+
+			ini = ParseModIni(data)
+		}
+
+		for _, dep := range ini.PrimaryList {
+			if err := expand(dep); err != nil {
+				return eris.Wrapf(err, "failed to load dependency of %s", mod)
+			}
+		}
+
+		result = append(result, folder)
+
+		for _, dep := range ini.SecondaryList {
+			if err := expand(dep); err != nil {
+				return eris.Wrapf(err, "failed to load dependency of %s", mod)
+			}
+		}
+
+		return nil
+	}
+
+	for _, mod := range mods {
+		if err := expand(mod); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,270 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var (
+	valueLinePattern = regexp.MustCompile(`^\s*([$+][^:]*):(.*)$`)
+	quotedPattern    = regexp.MustCompile(`"([^"]*)"`)
+	snippetEscaper   = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `}`, `\}`)
+)
+
+// completionScope is a container surrounding the cursor together with the nodes parsed for its
+// properties.
+type completionScope struct {
+	item     parser.ContainerItem
+	children []*parser.Node
+}
+
+// findScopes returns the containers the cursor is in, innermost first. line is 1-based and col
+// 0-based like parser ranges. The second result is false if the cursor is inside a multiline
+// value where no completions should be offered.
+func findScopes(schema []parser.ContainerItem, nodes []*parser.Node, line, col int) ([]completionScope, bool) {
+	root := parser.ContainerItem{Properties: make([]parser.ContainerChild, len(schema))}
+	for idx, item := range schema {
+		root.Properties[idx] = item
+	}
+
+	scopes := []completionScope{{item: root, children: nodes}}
+	for {
+		current := scopes[0]
+
+		// The last node starting before the cursor's line decides where we are; the line the
+		// cursor is on is still being written.
+		var last *parser.Node
+		for _, child := range current.children {
+			if child.Range[0] < line {
+				last = child
+			}
+		}
+		if last == nil {
+			return scopes, true
+		}
+
+		if last.Kind != parser.SectionNode {
+			inside := line < last.Range[2] || (line == last.Range[2] && col < last.Range[3])
+			return scopes, !inside
+		}
+
+		// #Sections end with #End, everything else ends at the next label of a parent.
+		if strings.HasPrefix(last.Label, "#") && line > last.Range[2] {
+			return scopes, true
+		}
+
+		item, found := current.item.Lookup(last.Label)
+		if !found {
+			return scopes, true
+		}
+
+		scopes = append([]completionScope{{item: item, children: last.Children}}, scopes...)
+	}
+}
+
+// completions returns the completion items for the cursor position in content.
+func completions(schema []parser.ContainerItem, nodes []*parser.Node, content string, line, col int) []protocol.CompletionItem {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return nil
+	}
+
+	text := []rune(strings.TrimRight(lines[line-1], "\r"))
+	if col > len(text) {
+		col = len(text)
+	}
+	prefix := string(text[:col])
+
+	scopes, ok := findScopes(schema, nodes, line, col)
+	if !ok {
+		return nil
+	}
+
+	if match := valueLinePattern.FindStringSubmatch(prefix); match != nil {
+		return valueCompletions(scopes, strings.TrimSpace(match[1]), match[2], line, col)
+	}
+
+	trimmed := strings.TrimLeft(prefix, " \t")
+	if trimmed != "" && !strings.ContainsAny(trimmed[:1], "$+#") {
+		return nil
+	}
+
+	return labelCompletions(scopes, line, col-len([]rune(trimmed)), col)
+}
+
+// labelCompletions offers the labels of all scopes in schema order. Labels of inner scopes come
+// first and labels which may only appear once are skipped if they're already present.
+func labelCompletions(scopes []completionScope, line, start, end int) []protocol.CompletionItem {
+	editRange := toRange([4]int{line, start, line, end})
+	snippet := protocol.InsertTextFormatSnippet
+	seen := make(map[string]bool)
+	result := make([]protocol.CompletionItem, 0)
+
+	for depth, scope := range scopes {
+		present := make(map[string]bool)
+		for _, child := range scope.children {
+			if child.Range[0] != line {
+				present[strings.ToLower(child.Label)] = true
+			}
+		}
+
+		for idx, item := range scope.item.Labels() {
+			key := strings.ToLower(item.Name)
+			if seen[key] || (!item.Multi && present[key]) {
+				continue
+			}
+			seen[key] = true
+
+			label := strings.TrimSuffix(item.Name, ":")
+			kind := labelKind(item)
+			sortText := fmt.Sprintf("%02d%04d", depth, idx)
+			completion := protocol.CompletionItem{
+				Label:            label,
+				Kind:             &kind,
+				SortText:         &sortText,
+				FilterText:       &label,
+				InsertTextFormat: &snippet,
+				TextEdit: protocol.TextEdit{
+					Range:   editRange,
+					NewText: labelSnippet(item),
+				},
+			}
+
+			if item.DeprecatedMessage != "" {
+				detail := item.DeprecatedMessage
+				completion.Detail = &detail
+				completion.Tags = []protocol.CompletionItemTag{protocol.CompletionItemTagDeprecated}
+			}
+
+			result = append(result, completion)
+		}
+	}
+
+	return result
+}
+
+func labelKind(item parser.ContainerItem) protocol.CompletionItemKind {
+	switch {
+	case strings.HasPrefix(item.Name, "#"):
+		return protocol.CompletionItemKindModule
+	case len(item.Labels()) > 0:
+		return protocol.CompletionItemKindClass
+	case parser.IsFlag(item.Value):
+		return protocol.CompletionItemKindKeyword
+	default:
+		return protocol.CompletionItemKindProperty
+	}
+}
+
+// labelSnippet builds the text inserted for item: its label, a placeholder for its value and the
+// required properties of sections.
+func labelSnippet(item parser.ContainerItem) string {
+	var sb strings.Builder
+	tabstop := 1
+	writeLabel := func(item parser.ContainerItem) {
+		sb.WriteString(snippetEscaper.Replace(strings.TrimSuffix(item.Name, ":")))
+
+		value := item.ValueType()
+		switch {
+		case item.BooleanContainer:
+			sb.WriteString(fmt.Sprintf(": ${%d|YES,NO|}", tabstop))
+			tabstop++
+		case value != nil && !parser.IsFlag(value):
+			sb.WriteString(fmt.Sprintf(": ${%d}", tabstop))
+			tabstop++
+		}
+	}
+
+	writeLabel(item)
+	for _, child := range item.Labels() {
+		if child.Required {
+			sb.WriteString("\n")
+			writeLabel(child)
+		}
+	}
+
+	if strings.HasPrefix(item.Name, "#") {
+		sb.WriteString("\n$0\n#End")
+	}
+
+	return sb.String()
+}
+
+// valueCompletions offers the allowed values of enums and flag lists. rest is the text between
+// the label's colon and the cursor.
+func valueCompletions(scopes []completionScope, label, rest string, line, col int) []protocol.CompletionItem {
+	var item parser.ContainerItem
+	found := false
+	for _, scope := range scopes {
+		if item, found = scope.item.Lookup(label); found {
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var allowed []string
+	quoted := false
+	start := col
+	switch value := item.ValueType().(type) {
+	case parser.Enum:
+		allowed = value.Allowed
+		start = col - len([]rune(strings.TrimLeft(rest, " \t")))
+	case parser.ValueList:
+		enum, ok := value.ValueParser.(parser.Enum)
+		if !ok {
+			return nil
+		}
+
+		allowed = enum.Allowed
+		quoted = true
+		if strings.Count(rest, `"`)%2 == 1 {
+			// Replace the flag that's being typed including its opening quote
+			start = col - len([]rune(rest[strings.LastIndex(rest, `"`):]))
+		}
+	default:
+		return nil
+	}
+
+	used := make(map[string]bool)
+	if quoted {
+		for _, match := range quotedPattern.FindAllStringSubmatch(rest, -1) {
+			used[strings.ToLower(match[1])] = true
+		}
+	}
+
+	editRange := toRange([4]int{line, start, line, col})
+	kind := protocol.CompletionItemKindEnumMember
+	result := make([]protocol.CompletionItem, 0, len(allowed))
+	for idx, value := range allowed {
+		value = strings.TrimSuffix(value, "*")
+		if used[strings.ToLower(value)] {
+			continue
+		}
+
+		text := value
+		if quoted {
+			text = `"` + value + `"`
+		}
+
+		sortText := fmt.Sprintf("%04d", idx)
+		filterText := text
+		result = append(result, protocol.CompletionItem{
+			Label:      value,
+			Kind:       &kind,
+			SortText:   &sortText,
+			FilterText: &filterText,
+			TextEdit: protocol.TextEdit{
+				Range:   editRange,
+				NewText: text,
+			},
+		})
+	}
+
+	return result
+}
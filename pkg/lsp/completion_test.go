@@ -0,0 +1,72 @@
+package lsp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestCompletions(t *testing.T) {
+	schema := []parser.ContainerItem{
+		structs.Required(structs.Section("#Ship Classes",
+			structs.Multi(structs.Section("$Name",
+				structs.Required(structs.StringValue("")),
+				structs.Required(structs.FloatValue("$Density")),
+				structs.BooleanFlag("+nocreate"),
+				structs.EnumValue("$Class Type", "Fighter", "Bomber"),
+				structs.StringFlagsValue("$Flags", "player_ship", "stealth", "ai_*"),
+			)),
+		)),
+		structs.Section("#Weapons",
+			structs.Multi(structs.StringValue("$Name")),
+		),
+	}
+
+	const table = "#Ship Classes\n" +
+		"$Name: GTF Ulysses\n" +
+		"$Density: 1\n" +
+		"\n" +
+		"$Class Type: Fi\n" +
+		"$Flags: ( \"stealth\" \"p\" )\n" +
+		"#End\n" +
+		"\n"
+
+	lexer := parser.NewLexer(context.Background(), strings.NewReader(table))
+	nodes, err := parser.ParseTable(lexer, schema)
+	if err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+
+	labels := func(items []protocol.CompletionItem) []string {
+		result := make([]string, len(items))
+		for idx, item := range items {
+			result[idx] = item.Label
+			if edit, ok := item.TextEdit.(protocol.TextEdit); ok {
+				result[idx] += "=" + edit.NewText
+			}
+		}
+		return result
+	}
+
+	tests := []struct {
+		line, col int
+		expected  []string
+	}{
+		// $Density, $Class Type and $Flags are already present
+		{4, 0, []string{"+nocreate=+nocreate", "$Name=\\$Name: ${1}\n\\$Density: ${2}", "#Weapons=#Weapons\n$0\n#End"}},
+		{5, 15, []string{"Fighter=Fighter", "Bomber=Bomber"}},
+		{6, 22, []string{"player_ship=\"player_ship\"", "ai_=\"ai_\""}},
+		{8, 0, []string{"#Weapons=#Weapons\n$0\n#End"}},
+	}
+
+	for _, test := range tests {
+		result := labels(completions(schema, nodes, table, test.line, test.col))
+		if strings.Join(result, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("line %d, col %d: expected %q but got %q", test.line, test.col, test.expected, result)
+		}
+	}
+}
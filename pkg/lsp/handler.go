@@ -3,11 +3,14 @@ package lsp
 import (
 	contextpkg "context"
 	"fmt"
+	"net/url"
+	"path"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/ngld/fso-table-parser/pkg/index"
 	"github.com/ngld/fso-table-parser/pkg/parser"
 	"github.com/ngld/fso-table-parser/pkg/structs"
 	"github.com/rotisserie/eris"
@@ -26,43 +29,102 @@ type docCacheEntry struct {
 	ctx       contextpkg.Context
 	ctxCancel contextpkg.CancelFunc
 	scopes    []parser.ScopeInfo
+	schema    []parser.ContainerItem
+	nodes     []*parser.Node
+	// symbols describes the names this table defines and references for the workspace index.
+	symbols []index.Rule
+	// engineVersion decides which ;;FSO x.y.z;; lines are active.
+	engineVersion parser.Version
 	sync.Mutex
 	version        int32
 	pendingVersion int32
 }
 
-func processLexerErrors(errors []error, severity protocol.DiagnosticSeverity, content string) []protocol.Diagnostic {
+// toRange converts a parser range to an LSP range.
+func toRange(loc [4]int) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{
+			Line:      uint32(loc[0] - 1),
+			Character: uint32(loc[1]),
+		},
+		End: protocol.Position{
+			Line:      uint32(loc[2] - 1),
+			Character: uint32(loc[3]),
+		},
+	}
+}
+
+func processLexerErrors(errors []error, severity protocol.DiagnosticSeverity, uri string) []protocol.Diagnostic {
 	msgs := make([]protocol.Diagnostic, len(errors))
 	for idx, err := range errors {
 		var loc [4]int
+		var related []protocol.DiagnosticRelatedInformation
 		if errInfo, ok := eris.Cause(err).(parser.ParserError); ok {
 			loc = errInfo.Location()
+			if msg, relatedLoc := errInfo.Related(); msg != "" {
+				related = []protocol.DiagnosticRelatedInformation{{
+					Location: protocol.Location{URI: uri, Range: toRange(relatedLoc)},
+					Message:  msg,
+				}}
+			}
 		} else {
 			loc = [4]int{0, 0, 0, 0}
 		}
 		msgs[idx] = protocol.Diagnostic{
-			Range: protocol.Range{
-				Start: protocol.Position{
-					Line:      uint32(loc[0] - 1),
-					Character: uint32(loc[1]),
-				},
-				End: protocol.Position{
-					Line:      uint32(loc[2] - 1),
-					Character: uint32(loc[3]),
-				},
-			},
+			Range:    toRange(loc),
 			Severity: &severity,
 			Code: &protocol.IntegerOrString{
 				Value: "fso-lsp-error",
 			},
-			Message: err.Error(),
+			Message:            err.Error(),
+			RelatedInformation: related,
+		}
+	}
+
+	return msgs
+}
+
+// versionDiagnostics greys out the lines disabled by version comments.
+func versionDiagnostics(regions []parser.VersionRegion, engineVersion parser.Version) []protocol.Diagnostic {
+	severity := protocol.DiagnosticSeverityHint
+	msgs := make([]protocol.Diagnostic, 0)
+	for _, region := range regions {
+		if region.Active {
+			continue
+		}
+
+		msg := fmt.Sprintf("Only used by FSO %s or newer (target: %s)", region.Version, engineVersion)
+		if region.Negated {
+			msg = fmt.Sprintf("Only used by FSO versions older than %s (target: %s)", region.Version, engineVersion)
 		}
+
+		msgs = append(msgs, protocol.Diagnostic{
+			Range:    toRange(region.Range),
+			Severity: &severity,
+			Code: &protocol.IntegerOrString{
+				Value: "fso-lsp-inactive",
+			},
+			Message: msg,
+			Tags:    []protocol.DiagnosticTag{protocol.DiagnosticTagUnnecessary},
+		})
 	}
 
 	return msgs
 }
 
-func analyseDoc(context *glsp.Context, doc *docCacheEntry, fields []parser.ContainerItem) {
+// initEngineVersion reads the engineVersion initialization option. The latest version is used if
+// it's missing.
+func initEngineVersion(options interface{}) (parser.Version, error) {
+	if values, ok := options.(map[string]interface{}); ok {
+		if value, ok := values["engineVersion"].(string); ok && value != "" {
+			return parser.ParseVersion(value)
+		}
+	}
+
+	return parser.LatestVersion, nil
+}
+
+func analyseDoc(context *glsp.Context, ws *workspace, doc *docCacheEntry) {
 	defer func() {
 		p := recover()
 		if p != nil {
@@ -75,25 +137,26 @@ func analyseDoc(context *glsp.Context, doc *docCacheEntry, fields []parser.Conta
 
 	protocol.Trace(context, protocol.MessageTypeInfo, fmt.Sprintf("Parsing %s", doc.uri))
 	start := time.Now()
-	lexer := parser.NewLexer(doc.ctx, strings.NewReader(doc.content))
+	versionOpt := parser.WithEngineVersion(doc.engineVersion)
+	// Keep parsing properties that are out of order to avoid follow-up errors
+	lexer := parser.NewLexer(doc.ctx, strings.NewReader(doc.content), versionOpt, parser.WithUnorderedProperties())
 
-	for _, container := range fields {
-		if doc.ctx.Err() != nil {
-			break
-		}
-		_, err := container.Parse(lexer)
-		if err != nil {
-			lexer.Report(err)
-		}
+	var nodes []*parser.Node
+	var err error
+	if doc.schema == nil {
+		// Unknown table, only build the tree without validating it
+		nodes, err = parser.ParseGeneric(doc.ctx, doc.content, versionOpt)
+	} else {
+		nodes, err = parser.ParseTable(lexer, doc.schema)
 	}
-
-	if doc.ctx.Err() != nil {
+	if err != nil {
 		protocol.Trace(context, protocol.MessageTypeInfo, fmt.Sprintf("Canceled %s (%v)", doc.uri, doc.ctx.Err()))
 		return
 	}
 
-	msgs := processLexerErrors(lexer.Errors(), protocol.DiagnosticSeverityError, doc.content)
-	msgs = append(msgs, processLexerErrors(lexer.Warnings(), protocol.DiagnosticSeverityInformation, doc.content)...)
+	msgs := processLexerErrors(lexer.Errors(), protocol.DiagnosticSeverityError, doc.uri)
+	msgs = append(msgs, processLexerErrors(lexer.Warnings(), protocol.DiagnosticSeverityInformation, doc.uri)...)
+	msgs = append(msgs, versionDiagnostics(lexer.VersionRegions(), doc.engineVersion)...)
 	end := time.Now()
 
 	version := uint32(doc.version)
@@ -103,17 +166,38 @@ func analyseDoc(context *glsp.Context, doc *docCacheEntry, fields []parser.Conta
 		Diagnostics: msgs,
 	})
 
+	doc.nodes = nodes
 	doc.scopes = lexer.ScopeInfos()
+	if len(doc.symbols) > 0 {
+		inactive, err := structs.InactiveSymbols(doc.ctx, uriPath(doc.uri), doc.content, doc.engineVersion)
+		if err != nil {
+			protocol.Trace(context, protocol.MessageTypeInfo, fmt.Sprintf("Canceled %s (%v)", doc.uri, doc.ctx.Err()))
+			return
+		}
+
+		ws.update(uriPath(doc.uri), doc.content, nodes, doc.symbols, inactive)
+	}
 
 	duration := end.Sub(start).Milliseconds()
 	protocol.Trace(context, protocol.MessageTypeInfo, fmt.Sprintf("Processed %s in %dms", doc.uri, duration))
 	doc.ctxCancel()
 }
 
+// uriFilename returns the file name of a document URI.
+func uriFilename(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err == nil && parsed.Path != "" {
+		uri = parsed.Path
+	}
+
+	return path.Base(uri)
+}
+
 func GetHandler() *protocol.Handler {
 	var handler *protocol.Handler
 	docCache := make(map[string]*docCacheEntry)
-	fields := structs.NewTestTable()
+	engineVersion := parser.LatestVersion
+	ws := newWorkspace()
 
 	handler = &protocol.Handler{
 		CancelRequest: func(context *glsp.Context, params *protocol.CancelParams) error {
@@ -130,8 +214,26 @@ func GetHandler() *protocol.Handler {
 			protocol.SetTraceValue(protocol.TraceValueVerbose)
 			protocol.Trace(context, protocol.MessageTypeInfo, fmt.Sprintf("Trace: %v", *params.Trace))
 
+			var err error
+			engineVersion, err = initEngineVersion(params.InitializationOptions)
+			if err != nil {
+				protocol.Trace(context, protocol.MessageTypeWarning, fmt.Sprintf("Ignoring engineVersion: %v", err))
+				engineVersion = parser.LatestVersion
+			}
+
+			roots := workspaceRoots(params)
+			indexVersion := engineVersion
+			go func() {
+				for _, root := range roots {
+					if err := ws.load(contextpkg.Background(), root, indexVersion); err != nil {
+						protocol.Trace(context, protocol.MessageTypeWarning, fmt.Sprintf("Failed to index %s: %v", root, err))
+					}
+				}
+			}()
+
 			caps := handler.CreateServerCapabilities()
 			caps.TextDocumentSync = protocol.TextDocumentSyncKindIncremental
+			caps.CompletionProvider.TriggerCharacters = []string{"$", "+", "#"}
 
 			protocol.Trace(context, protocol.MessageTypeInfo, "Hello World!")
 			return protocol.InitializeResult{
@@ -166,9 +268,14 @@ func GetHandler() *protocol.Handler {
 				uri:     doc.URI,
 				version: doc.Version,
 				content: doc.Text,
+				schema:  structs.LookupSchema(uriFilename(doc.URI)),
+				symbols: structs.LookupSymbols(uriFilename(doc.URI)),
+
+				engineVersion: engineVersion,
 			}
 
-			go analyseDoc(context, docCache[doc.URI], fields)
+			ws.setOpen(uriPath(doc.URI), true)
+			go analyseDoc(context, ws, docCache[doc.URI])
 			return nil
 		},
 		TextDocumentDidChange: func(context *glsp.Context, params *protocol.DidChangeTextDocumentParams) error {
@@ -200,7 +307,7 @@ func GetHandler() *protocol.Handler {
 
 				if item.pendingVersion == params.TextDocument.Version {
 					// Only trigger the analysis for the latest version
-					analyseDoc(context, item, fields)
+					analyseDoc(context, ws, item)
 				}
 				item.Unlock()
 			}()
@@ -209,9 +316,45 @@ func GetHandler() *protocol.Handler {
 		},
 		TextDocumentDidClose: func(context *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
 			delete(docCache, params.TextDocument.URI)
+			// Go back to the version on disk
+			ws.setOpen(uriPath(params.TextDocument.URI), false)
+			go ws.loadFile(contextpkg.Background(), uriPath(params.TextDocument.URI), engineVersion)
 			return nil
 		},
 
+		TextDocumentCompletion: func(context *glsp.Context, params *protocol.CompletionParams) (interface{}, error) {
+			doc, found := docCache[params.TextDocument.URI]
+			if !found {
+				return nil, eris.Errorf("Document %s not found", params.TextDocument.URI)
+			}
+
+			if doc.schema == nil {
+				// Generic mode doesn't know which labels are valid
+				return nil, nil
+			}
+
+			return completions(doc.schema, doc.nodes, doc.content, int(params.Position.Line)+1, int(params.Position.Character)), nil
+		},
+		TextDocumentDocumentSymbol: func(context *glsp.Context, params *protocol.DocumentSymbolParams) (interface{}, error) {
+			doc, found := docCache[params.TextDocument.URI]
+			if !found {
+				return nil, eris.Errorf("Document %s not found", params.TextDocument.URI)
+			}
+
+			return documentSymbols(doc.nodes), nil
+		},
+		TextDocumentDefinition: func(context *glsp.Context, params *protocol.DefinitionParams) (interface{}, error) {
+			return ws.lookup(params.TextDocument.URI, params.Position, true, false), nil
+		},
+		TextDocumentReferences: func(context *glsp.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+			return ws.lookup(params.TextDocument.URI, params.Position, false, params.Context.IncludeDeclaration), nil
+		},
+		TextDocumentRename: func(context *glsp.Context, params *protocol.RenameParams) (*protocol.WorkspaceEdit, error) {
+			return ws.rename(params.TextDocument.URI, params.Position, params.NewName)
+		},
+		WorkspaceSymbol: func(context *glsp.Context, params *protocol.WorkspaceSymbolParams) ([]protocol.SymbolInformation, error) {
+			return ws.search(params.Query), nil
+		},
 		TextDocumentHover: func(context *glsp.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
 			doc, found := docCache[params.TextDocument.URI]
 			if !found {
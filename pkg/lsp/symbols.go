@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// documentSymbols builds the outline of a table: #Sections become namespaces, entries named by
+// their value (like $Name: GTF Ulysses) classes and other sections with nested properties
+// objects. Plain properties are left out.
+func documentSymbols(nodes []*parser.Node) []protocol.DocumentSymbol {
+	result := make([]protocol.DocumentSymbol, 0)
+	for _, node := range nodes {
+		if node.Kind != parser.SectionNode {
+			continue
+		}
+
+		children := documentSymbols(node.Children)
+		labelRange := [4]int{node.Range[0], node.Range[1], node.Range[0], node.Range[1] + utf8.RuneCountInString(node.Label)}
+		symbol := protocol.DocumentSymbol{
+			Name:           node.Label,
+			Kind:           protocol.SymbolKindObject,
+			Range:          toRange(node.Range),
+			SelectionRange: toRange(labelRange),
+			Children:       children,
+		}
+
+		name := symbolName(node.InlineValue())
+		switch {
+		case strings.HasPrefix(node.Label, "#"):
+			symbol.Kind = protocol.SymbolKindNamespace
+		case name != "":
+			label := node.Label
+			symbol.Name = name
+			symbol.Detail = &label
+			symbol.Kind = protocol.SymbolKindClass
+			symbol.SelectionRange = toRange(node.InlineValueRange())
+		case !hasLabelledChild(node):
+			continue
+		}
+
+		result = append(result, symbol)
+	}
+
+	return result
+}
+
+// symbolName returns the name stored in an entry's value or an empty string if the value isn't a
+// name.
+func symbolName(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case parser.XSTR:
+		return strings.TrimSpace(value.Text)
+	case parser.Subsystem:
+		return strings.TrimSpace(value.Name)
+	default:
+		return ""
+	}
+}
+
+func hasLabelledChild(node *parser.Node) bool {
+	for _, child := range node.Children {
+		if child.Label != "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestDocumentSymbols(t *testing.T) {
+	const table = `#Ship Classes
+$Name: GTF Ulysses
+$Density: 1
+$Subsystem: Sensors, 10, 0
+$Subsystem: Communications, 5, 0
+$Name: GTF Hercules
+#End
+`
+
+	lexer := parser.NewLexer(context.Background(), strings.NewReader(table))
+	nodes, err := parser.ParseTable(lexer, structs.NewShipsTable())
+	if err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+	if len(lexer.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", lexer.Errors())
+	}
+
+	var describe func(symbols []protocol.DocumentSymbol, indent string) string
+	describe = func(symbols []protocol.DocumentSymbol, indent string) string {
+		result := ""
+		for _, symbol := range symbols {
+			result += fmt.Sprintf("%s%s %d %d:%d-%d:%d %d:%d\n", indent, symbol.Name, symbol.Kind,
+				symbol.Range.Start.Line, symbol.Range.Start.Character, symbol.Range.End.Line, symbol.Range.End.Character,
+				symbol.SelectionRange.Start.Line, symbol.SelectionRange.Start.Character)
+			result += describe(symbol.Children, indent+"  ")
+		}
+		return result
+	}
+
+	expected := `#Ship Classes 3 0:0-6:4 0:0
+  GTF Ulysses 5 1:0-4:32 1:7
+    Sensors 5 3:0-3:26 3:12
+    Communications 5 4:0-4:32 4:12
+  GTF Hercules 5 5:0-5:19 5:7
+`
+	if result := describe(documentSymbols(nodes), ""); result != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, result)
+	}
+}
+
+func TestDocumentSymbolsNonASCIILabels(t *testing.T) {
+	nodes, err := parser.ParseGeneric(context.Background(), "#Größen\n$Länge: 1\n#End\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	symbols := documentSymbols(nodes)
+	if len(symbols) != 1 || symbols[0].SelectionRange.End.Character != 7 {
+		t.Errorf("unexpected symbols %+v", symbols)
+	}
+}
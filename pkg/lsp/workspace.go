@@ -0,0 +1,264 @@
+package lsp
+
+import (
+	contextpkg "context"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+	"github.com/rotisserie/eris"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// workspace indexes the names defined and referenced by every table, mission and campaign in the
+// workspace folders.
+// Open documents replace the version on disk. Files are identified by their path.
+type workspace struct {
+	sync.Mutex
+	index *index.Index
+	// open contains the paths of open documents. Loading them from disk would replace the
+	// editor's content with an outdated version.
+	open map[string]bool
+	// sexpFiles contains the content of the indexed missions and campaigns. Renames are refused
+	// while their SEXPs still use the old name.
+	sexpFiles map[string]string
+}
+
+func newWorkspace() *workspace {
+	return &workspace{
+		index:     index.New(),
+		open:      make(map[string]bool),
+		sexpFiles: make(map[string]string),
+	}
+}
+
+// uriPath converts a document URI to a file path.
+func uriPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+
+	path := parsed.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		// Windows paths look like /C:/...
+		path = path[1:]
+	}
+
+	return filepath.FromSlash(path)
+}
+
+// pathURI converts a file path to a document URI.
+func pathURI(path string) string {
+	if strings.Contains(path, "://") {
+		return path
+	}
+
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// workspaceRoots returns the folders opened by the client.
+func workspaceRoots(params *protocol.InitializeParams) []string {
+	result := make([]string, 0)
+	for _, folder := range params.WorkspaceFolders {
+		result = append(result, uriPath(folder.URI))
+	}
+
+	if len(result) == 0 {
+		if params.RootURI != nil {
+			result = append(result, uriPath(*params.RootURI))
+		} else if params.RootPath != nil {
+			result = append(result, *params.RootPath)
+		}
+	}
+
+	return result
+}
+
+// load indexes every table, mission and campaign below root. Files that can't be read or parsed are skipped.
+// version decides which ;;FSO x.y.z;; lines are active.
+func (w *workspace) load(ctx contextpkg.Context, root string, version parser.Version) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		w.loadFile(ctx, path, version)
+		return nil
+	})
+}
+
+// loadFile (re)indexes a table, mission or campaign from disk. It's dropped from the index if it
+// can't be read. Open documents are skipped.
+func (w *workspace) loadFile(ctx contextpkg.Context, path string, version parser.Version) {
+	rules := structs.LookupSymbols(filepath.Base(path))
+	if len(rules) == 0 {
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		w.Lock()
+		if !w.open[path] {
+			w.index.Remove(path)
+			delete(w.sexpFiles, path)
+		}
+		w.Unlock()
+		return
+	}
+
+	var nodes []*parser.Node
+	versionOpt := parser.WithEngineVersion(version)
+	if schema := structs.LookupSchema(filepath.Base(path)); schema == nil {
+		nodes, err = parser.ParseGeneric(ctx, string(content), versionOpt)
+	} else {
+		nodes, err = parser.ParseTable(parser.NewLexer(ctx, strings.NewReader(string(content)), versionOpt), schema)
+	}
+	if err != nil {
+		return
+	}
+
+	inactive, err := structs.InactiveSymbols(ctx, path, string(content), version)
+	if err != nil {
+		return
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	// The document might have been opened while it was parsed
+	if !w.open[path] {
+		w.index.Remove(path)
+		w.index.Add(path, string(content), nodes, rules)
+		w.index.AddSymbols(inactive)
+		w.setSexpFile(path, string(content))
+	}
+}
+
+// setOpen marks path as opened or closed by the client.
+func (w *workspace) setOpen(path string, open bool) {
+	w.Lock()
+	defer w.Unlock()
+
+	if open {
+		w.open[path] = true
+	} else {
+		delete(w.open, path)
+	}
+}
+
+// update replaces the symbols of the open document path with the ones found in nodes and the
+// inactive ones. Documents closed in the meantime keep the version loaded from disk.
+func (w *workspace) update(path, content string, nodes []*parser.Node, rules []index.Rule, inactive []index.Symbol) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.open[path] {
+		w.index.Remove(path)
+		w.index.Add(path, content, nodes, rules)
+		w.index.AddSymbols(inactive)
+		w.setSexpFile(path, content)
+	}
+}
+
+// setSexpFile remembers the content of path if it's a mission or campaign. The caller has to hold the lock.
+func (w *workspace) setSexpFile(path, content string) {
+	if structs.UsesSexps(path) {
+		w.sexpFiles[path] = content
+	}
+}
+
+// lookup returns the definitions of the symbol at the given position or its references, optionally
+// preceded by its definitions.
+func (w *workspace) lookup(uri string, position protocol.Position, definitions, includeDeclaration bool) []protocol.Location {
+	w.Lock()
+	defer w.Unlock()
+
+	symbol, found := w.index.SymbolAt(uriPath(uri), int(position.Line)+1, int(position.Character))
+	if !found {
+		return nil
+	}
+
+	symbols := w.index.References(symbol.Kind, symbol.Name)
+	if definitions {
+		symbols = w.index.Definitions(symbol.Kind, symbol.Name)
+	} else if includeDeclaration {
+		symbols = append(w.index.Definitions(symbol.Kind, symbol.Name), symbols...)
+	}
+
+	return symbolLocations(symbols)
+}
+
+// rename renames the symbol at the given position in every file that defines or references it.
+func (w *workspace) rename(uri string, position protocol.Position, newName string) (*protocol.WorkspaceEdit, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	symbol, found := w.index.SymbolAt(uriPath(uri), int(position.Line)+1, int(position.Character))
+	if !found {
+		return nil, eris.New("There is no ship class, weapon or other name to rename here")
+	}
+
+	edits, err := w.index.Rename(symbol.Kind, symbol.Name, newName, index.RefuseUnindexedUses(w.sexpFiles))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &protocol.WorkspaceEdit{Changes: make(map[protocol.DocumentUri][]protocol.TextEdit)}
+	for _, edit := range edits {
+		fileURI := pathURI(edit.File)
+		result.Changes[fileURI] = append(result.Changes[fileURI], protocol.TextEdit{
+			Range:   toRange(edit.Range),
+			NewText: edit.NewText,
+		})
+	}
+
+	return result, nil
+}
+
+func symbolLocations(symbols []index.Symbol) []protocol.Location {
+	result := make([]protocol.Location, len(symbols))
+	for idx, symbol := range symbols {
+		result[idx] = protocol.Location{
+			URI:   pathURI(symbol.File),
+			Range: toRange(symbol.Range),
+		}
+	}
+
+	return result
+}
+
+// search returns the definitions matching query for workspace/symbol.
+func (w *workspace) search(query string) []protocol.SymbolInformation {
+	w.Lock()
+	defer w.Unlock()
+
+	symbols := w.index.Search(query)
+	result := make([]protocol.SymbolInformation, len(symbols))
+	for idx, symbol := range symbols {
+		container := string(symbol.Kind)
+		result[idx] = protocol.SymbolInformation{
+			Name:          symbol.Name,
+			Kind:          protocol.SymbolKindClass,
+			Location:      symbolLocations([]index.Symbol{symbol})[0],
+			ContainerName: &container,
+		}
+	}
+
+	return result
+}
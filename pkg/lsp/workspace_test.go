@@ -0,0 +1,173 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestWorkspaceLookup(t *testing.T) {
+	root := t.TempDir()
+	tables := filepath.Join(root, "data", "tables")
+	if err := os.MkdirAll(tables, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"weapons.tbl": "#Primary Weapons\n$Name: Subach HL-7\n" +
+			";;FSO 23.0.0;; $Name: Prometheus S\n" +
+			";;!FSO 23.0.0;; $Name: Prometheus R\n" +
+			"#End\n",
+		"armor.tbl": "#Armor Type\n$Name: Light\n#End\n",
+		"ships.tbl": "#Ship Classes\n" +
+			"$Name: GTF Ulysses\n" +
+			"$Default PBanks: ( \"Subach HL-7\" )\n" +
+			"$Armor Type: Light\n" +
+			"$Name: GTF Ulysses#2\n" +
+			"+Use Template: GTF Ulysses\n" +
+			"$Default PBanks: ( \"Subach HL-7\" )\n" +
+			"#End\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tables, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws := newWorkspace()
+	if err := ws.load(context.Background(), root, parser.Version{22, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	ships := pathURI(filepath.Join(tables, "ships.tbl"))
+	describe := func(locations []protocol.Location) []string {
+		result := make([]string, len(locations))
+		for idx, location := range locations {
+			result[idx] = fmt.Sprintf("%s:%d", filepath.Base(uriPath(location.URI)), location.Range.Start.Line)
+		}
+		return result
+	}
+
+	tests := []struct {
+		line, col   uint32
+		definitions bool
+		expected    []string
+	}{
+		// +Use Template: GTF Ulysses
+		{5, 18, true, []string{"ships.tbl:1"}},
+		// $Armor Type: Light
+		{3, 14, true, []string{"armor.tbl:1"}},
+		// References to Subach HL-7 including its definition
+		{2, 22, false, []string{"weapons.tbl:1", "ships.tbl:2", "ships.tbl:6"}},
+		// $Name: GTF Ulysses
+		{1, 10, false, []string{"ships.tbl:1", "ships.tbl:5"}},
+	}
+
+	for _, test := range tests {
+		result := describe(ws.lookup(ships, protocol.Position{Line: test.line, Character: test.col}, test.definitions, true))
+		if len(result) != len(test.expected) {
+			t.Errorf("%d:%d: expected %v but got %v", test.line, test.col, test.expected, result)
+			continue
+		}
+
+		for idx := range result {
+			if result[idx] != test.expected[idx] {
+				t.Errorf("%d:%d: expected %v but got %v", test.line, test.col, test.expected, result)
+				break
+			}
+		}
+	}
+
+	if symbols := ws.search("prometheus"); len(symbols) != 1 || symbols[0].Name != "Prometheus R" {
+		t.Errorf("expected only the weapon for older versions but got %+v", symbols)
+	}
+
+	// Prometheus S only exists in newer engine builds, but renaming would still break those
+	weapons := pathURI(filepath.Join(tables, "weapons.tbl"))
+	if _, err := ws.rename(weapons, protocol.Position{Line: 3, Character: 25}, "Prometheus S"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error for the weapon of newer versions but got %v", err)
+	}
+
+	if symbols := ws.search("ulysses"); len(symbols) != 2 || symbols[1].Name != "GTF Ulysses#2" {
+		t.Errorf("unexpected workspace symbols %+v", symbols)
+	}
+
+	// $Name: GTF Ulysses
+	position := protocol.Position{Line: 1, Character: 10}
+	if _, err := ws.rename(ships, position, "GTF Ulysses#2"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error for an existing ship class but got %v", err)
+	}
+
+	edit, err := ws.rename(ships, position, "GTF Odysseus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edits := edit.Changes[ships]; len(edits) != 2 || edits[1].Range.Start.Line != 5 || edits[1].NewText != "GTF Odysseus" {
+		t.Errorf("unexpected edits %+v", edit.Changes)
+	}
+}
+
+func TestWorkspaceOpenDocuments(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "weapons.tbl")
+	if err := os.WriteFile(path, []byte("#Primary Weapons\n$Name: Subach HL-7\n#End\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	const edited = "#Primary Weapons\n$Name: Subach HL-8\n#End\n"
+	nodes, err := parser.ParseGeneric(context.Background(), edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := newWorkspace()
+	ws.setOpen(path, true)
+	ws.update(path, edited, nodes, structs.LookupSymbols("weapons.tbl"), nil)
+
+	// The disk version must not replace the editor's content
+	if err := ws.load(context.Background(), root, parser.LatestVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	if symbols := ws.search("subach"); len(symbols) != 1 || symbols[0].Name != "Subach HL-8" {
+		t.Errorf("expected the open document's symbols but got %+v", symbols)
+	}
+
+	ws.setOpen(path, false)
+	ws.loadFile(context.Background(), path, parser.LatestVersion)
+	if symbols := ws.search("subach"); len(symbols) != 1 || symbols[0].Name != "Subach HL-7" {
+		t.Errorf("expected the symbols from disk after closing the document but got %+v", symbols)
+	}
+}
+
+func TestWorkspaceRenameSexpArguments(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"ships.tbl": "#Ship Classes\n$Name: GTF Ulysses\n#End\n",
+		"sm1-01.fs2": "#Objects\n\n$Name: Alpha 1\n$Class: GTF Ulysses\n\n" +
+			"#Events\n\n$Formula: ( when\n   ( is-ship-class \"GTF Ulysses\" \"Alpha 1\" )\n)\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ws := newWorkspace()
+	if err := ws.load(context.Background(), root, parser.LatestVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	ships := pathURI(filepath.Join(root, "ships.tbl"))
+	_, err := ws.rename(ships, protocol.Position{Line: 1, Character: 10}, "GTF Odysseus")
+	if err == nil || !strings.Contains(err.Error(), "sm1-01.fs2:9") {
+		t.Errorf("expected an error for the SEXP argument but got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package merge
+
+import (
+	"context"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/rotisserie/eris"
+)
+
+// SortModularTables sorts modular table names in the order FSO parses them. The engine lists
+// them with CF_SORT_REVERSE which results in reverse alphabetical order (ignoring case).
+func SortModularTables(names []string) {
+	sort.SliceStable(names, func(a, b int) bool {
+		return strings.ToLower(names[a]) > strings.ToLower(names[b])
+	})
+}
+
+// ParseFile parses a single table file. Parse errors are returned as the second value.
+func ParseFile(ctx context.Context, fsys fs.FS, name string, schema []parser.ContainerItem, opts ...parser.LexerOption) ([]*parser.Node, []error, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "failed to read %s", name)
+	}
+
+	lexer := parser.NewLexer(ctx, strings.NewReader(string(content)), opts...)
+	nodes, err := parser.ParseTable(lexer, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nodes, lexer.Errors(), nil
+}
+
+// LoadDir parses the base table and every modular table ending in suffix (e.g. "-shp.tbm") from
+// the top level of fsys and merges them in FSO's load order. Parse errors are included in the
+// merger's warnings.
+func LoadDir(ctx context.Context, fsys fs.FS, base, suffix string, schema []parser.ContainerItem, opts ...parser.LexerOption) (*Merger, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list tables")
+	}
+
+	baseFile := ""
+	modular := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := strings.ToLower(entry.Name())
+		if name == strings.ToLower(base) {
+			baseFile = entry.Name()
+		} else if strings.HasSuffix(name, strings.ToLower(suffix)) {
+			modular = append(modular, entry.Name())
+		}
+	}
+
+	SortModularTables(modular)
+	files := modular
+	if baseFile != "" {
+		files = append([]string{baseFile}, modular...)
+	}
+
+	merger := NewMerger(schema)
+	for _, file := range files {
+		if err := merger.ApplyFile(ctx, fsys, file, file, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	return merger, nil
+}
+
+// ApplyFile parses the table name from fsys and merges it into the result. Parse errors are
+// added to the warnings; label identifies the file in warnings.
+func (m *Merger) ApplyFile(ctx context.Context, fsys fs.FS, name, label string, opts ...parser.LexerOption) error {
+	nodes, parseErrors, err := ParseFile(ctx, fsys, name, m.schema, opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, parseErr := range parseErrors {
+		m.warnings = append(m.warnings, eris.Wrap(parseErr, label))
+	}
+
+	m.Apply(label, nodes)
+	return nil
+}
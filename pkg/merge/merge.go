@@ -0,0 +1,274 @@
+package merge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/rotisserie/eris"
+)
+
+// Merger combines a base table with its modular tables the way FSO does. Files have to be
+// applied in load order: the base table first and then every modular table.
+type Merger struct {
+	schema   []parser.ContainerItem
+	result   []*parser.Node
+	warnings []error
+	file     string
+}
+
+func NewMerger(schema []parser.ContainerItem) *Merger {
+	return &Merger{
+		schema: schema,
+		result: make([]*parser.Node, 0),
+	}
+}
+
+// Result returns the effective table after all applied files.
+func (m *Merger) Result() []*parser.Node {
+	return m.result
+}
+
+// Warnings returns the problems found while loading and merging files.
+func (m *Merger) Warnings() []error {
+	return m.warnings
+}
+
+func (m *Merger) warnf(node *parser.Node, msg string, args ...interface{}) {
+	err := parser.NewParserError(fmt.Sprintf(msg, args...), node.Range)
+	m.warnings = append(m.warnings, eris.Wrap(err, m.file))
+}
+
+// Apply merges the nodes parsed from file into the result.
+func (m *Merger) Apply(file string, nodes []*parser.Node) {
+	m.file = file
+	for _, node := range nodes {
+		var item parser.ContainerItem
+		found := false
+		for _, candidate := range m.schema {
+			if strings.EqualFold(candidate.Name, node.Label) {
+				item = candidate
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			m.warnf(node, "Section %s is not part of the schema", node.Label)
+			continue
+		}
+
+		var target *parser.Node
+		for _, existing := range m.result {
+			if strings.EqualFold(existing.Label, node.Label) {
+				target = existing
+				break
+			}
+		}
+
+		if target == nil {
+			target = &parser.Node{
+				Kind:  node.Kind,
+				Label: node.Label,
+				Range: node.Range,
+			}
+			m.result = append(m.result, target)
+		}
+
+		m.mergeInto(item, target, node)
+	}
+}
+
+// isDirective returns true for flags that control merging and don't end up in the final table.
+func isDirective(label string) bool {
+	switch strings.ToLower(label) {
+	case "+nocreate", "+remove", "+use template", "+noreplace":
+		return true
+	default:
+		return false
+	}
+}
+
+// isEntry returns true if item describes named entries like $Name or $Subsystem which modular
+// tables modify by name.
+func isEntry(item parser.ContainerItem) bool {
+	if !item.Multi || len(item.Properties) == 0 {
+		return false
+	}
+
+	first, ok := item.Properties[0].(parser.ContainerItem)
+	return ok && first.Name == "" && first.Value != nil
+}
+
+// EntryName returns the name of an entry like $Name or $Subsystem.
+func EntryName(node *parser.Node) string {
+	switch value := node.InlineValue().(type) {
+	case string:
+		return value
+	case parser.Subsystem:
+		return value.Name
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func findEntry(parent *parser.Node, label, name string) *parser.Node {
+	for _, child := range parent.Children {
+		if strings.EqualFold(child.Label, label) && strings.EqualFold(EntryName(child), name) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+func removeChild(parent, child *parser.Node) {
+	for idx, candidate := range parent.Children {
+		if candidate == child {
+			parent.Children = append(parent.Children[:idx], parent.Children[idx+1:]...)
+			return
+		}
+	}
+}
+
+func (m *Merger) mergeInto(item parser.ContainerItem, target, source *parser.Node) {
+	if source.Value != nil {
+		target.Value = source.Value
+		target.ValueRange = source.ValueRange
+	}
+
+	noreplace := source.Child("+noreplace") != nil
+	valueIdx := 0
+	for _, child := range source.Children {
+		if child.Kind == parser.ValueNode {
+			m.mergeValue(target, valueIdx, child, noreplace)
+			valueIdx++
+			continue
+		}
+
+		if isDirective(child.Label) {
+			continue
+		}
+
+		childItem, ok := item.Lookup(child.Label)
+		if !ok || (childItem.Multi && !isEntry(childItem)) {
+			target.Children = append(target.Children, child.Clone())
+			continue
+		}
+
+		if isEntry(childItem) {
+			m.mergeEntry(childItem, target, child)
+			continue
+		}
+
+		existing := target.Child(child.Label)
+		switch {
+		case existing == nil:
+			target.Children = append(target.Children, child.Clone())
+		case existing.Kind == parser.SectionNode && child.Kind == parser.SectionNode:
+			m.mergeInto(childItem, existing, child)
+		default:
+			*existing = *child.Clone()
+		}
+	}
+}
+
+// mergeValue replaces the idx-th unlabelled value of target. If noreplace is set and both values
+// are lists, the new items are added to the existing list instead.
+func (m *Merger) mergeValue(target *parser.Node, idx int, value *parser.Node, noreplace bool) {
+	valueIdx := 0
+	for childIdx, child := range target.Children {
+		if child.Kind != parser.ValueNode {
+			continue
+		}
+
+		if valueIdx < idx {
+			valueIdx++
+			continue
+		}
+
+		oldList, oldIsList := child.Value.([]interface{})
+		newList, newIsList := value.Value.([]interface{})
+		if noreplace && oldIsList && newIsList {
+			merged := make([]interface{}, len(oldList), len(oldList)+len(newList))
+			copy(merged, oldList)
+			for _, item := range newList {
+				if !containsValue(merged, item) {
+					merged = append(merged, item)
+				}
+			}
+
+			child.Value = merged
+			return
+		}
+
+		target.Children[childIdx] = value.Clone()
+		return
+	}
+
+	// Unlabelled values always come first
+	target.Children = append(target.Children, nil)
+	copy(target.Children[idx+1:], target.Children[idx:])
+	target.Children[idx] = value.Clone()
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	str, isString := value.(string)
+	for _, item := range list {
+		if isString {
+			if other, ok := item.(string); ok && strings.EqualFold(str, other) {
+				return true
+			}
+		} else if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Merger) mergeEntry(item parser.ContainerItem, parent, entry *parser.Node) {
+	name := EntryName(entry)
+	existing := findEntry(parent, entry.Label, name)
+
+	if entry.Child("+remove") != nil {
+		if existing == nil {
+			m.warnf(entry, "Can't remove %s %s because it doesn't exist", entry.Label, name)
+		} else {
+			removeChild(parent, existing)
+		}
+		return
+	}
+
+	if existing == nil {
+		if entry.Child("+nocreate") != nil {
+			m.warnf(entry, "Skipping %s %s because it doesn't exist and +nocreate is set", entry.Label, name)
+			return
+		}
+
+		existing = &parser.Node{
+			Kind:  entry.Kind,
+			Label: entry.Label,
+			Range: entry.Range,
+		}
+
+		if template := entry.Child("+Use Template"); template != nil {
+			templateName, _ := template.Value.(string)
+			original := findEntry(parent, entry.Label, templateName)
+			if original == nil {
+				m.warnf(template, "Template %s for %s was not found", templateName, name)
+			} else {
+				existing = original.Clone()
+				existing.Range = entry.Range
+			}
+		}
+
+		parent.Children = append(parent.Children, existing)
+	} else if template := entry.Child("+Use Template"); template != nil {
+		m.warnf(template, "Ignoring +Use Template because %s already exists", name)
+	}
+
+	m.mergeInto(item, existing, entry)
+}
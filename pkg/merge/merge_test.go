@@ -0,0 +1,69 @@
+package merge
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ngld/fso-table-parser/pkg/structs"
+)
+
+func TestLoadDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ships.tbl": {Data: []byte(`#Ship Classes
+$Name: GTF Ulysses
+$Density: 1
+$Flags: ( "player_ship" )
+$Name: GTF Hercules
+$Density: 2
+#End
+`)},
+		"b-shp.tbm": {Data: []byte(`#Ship Classes
+$Name: GTF Ulysses
++nocreate
+$Density: 3
+$Flags: ( "stealth" )
++noreplace
+$Name: GTF Hercules
++remove
+#End
+`)},
+		"a-shp.tbm": {Data: []byte(`#Ship Classes
+$Name: GTF Ulysses
++nocreate
+$Density: 4
+$Name: GTF Ulysses#2
++Use Template: GTF Ulysses
+#End
+`)},
+	}
+
+	merger, err := LoadDir(context.Background(), fsys, "ships.tbl", "-shp.tbm", structs.NewShipsTable())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, warning := range merger.Warnings() {
+		t.Errorf("Unexpected warning: %s", warning)
+	}
+
+	entries := merger.Result()[0].ChildrenNamed("$Name")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 ships but found %d", len(entries))
+	}
+
+	for _, entry := range entries {
+		if density := entry.Child("$Density").Value; density != 4.0 {
+			t.Errorf("%s: Expected density 4 but found %v", EntryName(entry), density)
+		}
+
+		flags := entry.Child("$Flags").InlineValue().([]interface{})
+		if len(flags) != 2 {
+			t.Errorf("%s: Expected 2 flags but found %v", EntryName(entry), flags)
+		}
+	}
+
+	if name := EntryName(entries[1]); name != "GTF Ulysses#2" {
+		t.Errorf("Expected the template copy to be named GTF Ulysses#2 but found %s", name)
+	}
+}
@@ -0,0 +1,86 @@
+package parser
+
+import "github.com/rotisserie/eris"
+
+// StripComments replaces every comment in content with spaces the same way the engine does
+// before parsing a table:
+//
+//   - ; comments out the rest of the line
+//   - /* ... */ and !* ... *! comment out everything in between and can span several lines
+//
+// Comment markers inside quotes are ignored, even if the quote spans several lines like
+// multi-line XSTRs do. Line breaks are kept so positions in the result match the original
+// content. The returned errors point at the opening marker of unterminated block comments.
+func StripComments(content string) (string, []error) {
+	src := []rune(content)
+	errors := make([]error, 0)
+
+	var closer rune
+	var opened [2]int
+	quoted := false
+	line, col := 1, 0
+
+	blank := func(idx int) {
+		if src[idx] != '\n' && src[idx] != '\r' {
+			src[idx] = ' '
+		}
+	}
+
+	for idx := 0; idx < len(src); idx++ {
+		char := src[idx]
+		next := rune(0)
+		if idx+1 < len(src) {
+			next = src[idx+1]
+		}
+
+		switch {
+		case closer != 0:
+			if char == '*' && next == closer {
+				blank(idx)
+				blank(idx + 1)
+				idx++
+				col++
+				closer = 0
+			} else {
+				blank(idx)
+			}
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+		case char == ';':
+			for idx < len(src) && src[idx] != '\n' {
+				blank(idx)
+				idx++
+				col++
+			}
+			idx--
+			col--
+		case (char == '/' || char == '!') && next == '*':
+			closer = char
+			opened = [2]int{line, col}
+			blank(idx)
+			blank(idx + 1)
+			idx++
+			col++
+		}
+
+		if src[idx] == '\n' {
+			line++
+			col = 0
+		} else {
+			col++
+		}
+	}
+
+	if closer != 0 {
+		marker := "/*"
+		if closer == '!' {
+			marker = "!*"
+		}
+
+		location := [4]int{opened[0], opened[1], opened[0], opened[1] + 2}
+		errors = append(errors, eris.Wrap(NewParserError("Unterminated "+marker+" comment", location), ""))
+	}
+
+	return string(src), errors
+}
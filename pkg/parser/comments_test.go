@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func trimLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, " \t\r")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// TestStripComments checks every table in testdata/comments against the .stripped file next to
+// it. Expected errors are listed in the optional .errors file.
+func TestStripComments(t *testing.T) {
+	files, err := filepath.Glob("testdata/comments/*.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		base := strings.TrimSuffix(file, ".tbl")
+		input, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := os.ReadFile(base + ".stripped")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expectedErrors := ""
+		if data, err := os.ReadFile(base + ".errors"); err == nil {
+			expectedErrors = strings.TrimSpace(string(data))
+		}
+
+		output, errors := StripComments(string(input))
+		if trimLines(output) != trimLines(string(expected)) {
+			t.Errorf("%s: unexpected output:\n%s", file, output)
+		}
+
+		// Positions have to stay the same
+		inputLines := strings.Split(string(input), "\n")
+		outputLines := strings.Split(output, "\n")
+		if len(inputLines) != len(outputLines) {
+			t.Errorf("%s: expected %d lines but got %d", file, len(inputLines), len(outputLines))
+		} else {
+			for idx := range inputLines {
+				if len([]rune(inputLines[idx])) != len([]rune(outputLines[idx])) {
+					t.Errorf("%s:%d: line length changed", file, idx+1)
+				}
+			}
+		}
+
+		messages := make([]string, len(errors))
+		for idx, err := range errors {
+			messages[idx] = err.Error()
+		}
+		if strings.Join(messages, "\n") != expectedErrors {
+			t.Errorf("%s: expected errors %q but got %q", file, expectedErrors, messages)
+		}
+
+		tree, err := ParseCST(context.Background(), string(input))
+		if err != nil {
+			t.Fatalf("%s: %+v", file, err)
+		}
+
+		if tree.String() != string(input) {
+			t.Errorf("%s: CST output differs from input", file)
+		}
+	}
+}
+
+func TestLexerComments(t *testing.T) {
+	schema := []ContainerItem{{
+		Name: "#Ship Classes",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Density", Value: IntegerValue},
+				ContainerItem{Name: "$Mass", Value: IntegerValue},
+			},
+		}},
+	}}
+
+	data, err := os.ReadFile("testdata/comments/block.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lexer := NewLexer(context.Background(), strings.NewReader(string(data)))
+	nodes, err := ParseTable(lexer, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lexer.Errors()) != 0 {
+		t.Errorf("unexpected errors %v", lexer.Errors())
+	}
+
+	entries := nodes[0].ChildrenNamed("$Name")
+	if len(entries) != 1 {
+		t.Fatalf("expected one $Name but got %d", len(entries))
+	}
+
+	name := entries[0].Children[0]
+	if name.Value != "GTF Ulysses" || name.ValueRange != [4]int{2, 20, 2, 31} {
+		t.Errorf("unexpected name %#v", name)
+	}
+
+	if density := entries[0].Child("$Density"); density == nil || density.Value != 1 || density.Range[0] != 6 {
+		t.Errorf("unexpected density %#v", density)
+	}
+
+	if mass := entries[0].Child("$Mass"); mass == nil || mass.Value != 2 {
+		t.Errorf("unexpected mass %#v", mass)
+	}
+}
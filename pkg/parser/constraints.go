@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rotisserie/eris"
+)
+
+// Constraint checks a parsed value. Violations are reported as warnings since the engine usually
+// clamps or ignores such values instead of refusing to load the table.
+type Constraint interface {
+	// Check returns an empty string if the value is valid and a description of the problem
+	// otherwise.
+	Check(value interface{}) string
+	// Describe explains the valid values for the hover text, e.g. "0 to 1". It's empty if the
+	// constraint can't be summarised.
+	Describe() string
+}
+
+type constraint struct {
+	check       func(value interface{}) string
+	description string
+}
+
+func (c constraint) Check(value interface{}) string { return c.check(value) }
+
+func (c constraint) Describe() string { return c.description }
+
+// checkConstraints reports a warning for every constraint value violates.
+func checkConstraints(lex *Lexer, constraints []Constraint, value interface{}, valueRange [4]int) {
+	for _, constraint := range constraints {
+		if msg := constraint.Check(value); msg != "" {
+			lex.ReportWarning(eris.Wrap(NewParserError(msg, valueRange), ""))
+		}
+	}
+}
+
+// numbers returns the numeric values contained in value. Lists return one entry per item.
+func numbers(value interface{}) []float64 {
+	switch value := value.(type) {
+	case int:
+		return []float64{float64(value)}
+	case float64:
+		return []float64{value}
+	case []float64:
+		return value
+	case []int:
+		result := make([]float64, len(value))
+		for idx, item := range value {
+			result[idx] = float64(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]float64, 0, len(value))
+		for _, item := range value {
+			result = append(result, numbers(item)...)
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// AtLeast requires every number in the value to be >= min.
+func AtLeast(min float64) Constraint {
+	return constraint{
+		description: "at least " + formatNumber(min),
+		check: func(value interface{}) string {
+			for _, number := range numbers(value) {
+				if number < min {
+					return fmt.Sprintf("Value %s must be at least %s", formatNumber(number), formatNumber(min))
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// AtMost requires every number in the value to be <= max.
+func AtMost(max float64) Constraint {
+	return constraint{
+		description: "at most " + formatNumber(max),
+		check: func(value interface{}) string {
+			for _, number := range numbers(value) {
+				if number > max {
+					return fmt.Sprintf("Value %s must be at most %s", formatNumber(number), formatNumber(max))
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// Between requires every number in the value to be within [min, max].
+func Between(min, max float64) Constraint {
+	return constraint{
+		description: formatNumber(min) + " to " + formatNumber(max),
+		check: func(value interface{}) string {
+			for _, number := range numbers(value) {
+				if number < min || number > max {
+					return fmt.Sprintf("Value %s must be between %s and %s", formatNumber(number), formatNumber(min), formatNumber(max))
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// Positive requires every number in the value to be > 0.
+func Positive() Constraint {
+	return constraint{
+		description: "greater than 0",
+		check: func(value interface{}) string {
+			for _, number := range numbers(value) {
+				if number <= 0 {
+					return fmt.Sprintf("Value %s must be greater than 0", formatNumber(number))
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// NonNegative requires every number in the value to be >= 0.
+func NonNegative() Constraint {
+	return AtLeast(0)
+}
+
+// Fraction requires values between 0 and 1 (e.g. 0.25 for 25%).
+func Fraction() Constraint {
+	return Between(0, 1)
+}
+
+// Percent requires values between 0 and 100.
+func Percent() Constraint {
+	return Between(0, 100)
+}
+
+// Ascending requires the numbers in a list to be in ascending order. Equal neighbours are
+// allowed.
+func Ascending() Constraint {
+	return constraint{
+		description: "ascending",
+		check: func(value interface{}) string {
+			items := numbers(value)
+			for idx := 1; idx < len(items); idx++ {
+				if items[idx] < items[idx-1] {
+					return fmt.Sprintf("Values must be in ascending order but %s follows %s", formatNumber(items[idx]), formatNumber(items[idx-1]))
+				}
+			}
+			return ""
+		},
+	}
+}
+
+// Check reports msg if check returns false for the value.
+func Check(check func(value interface{}) bool, msg string) Constraint {
+	return constraint{
+		check: func(value interface{}) string {
+			if check(value) {
+				return ""
+			}
+			return msg
+		},
+	}
+}
@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"strings"
 
 	"github.com/rotisserie/eris"
@@ -11,8 +14,8 @@ type ParseItem interface {
 }
 
 type ContainerChild interface {
-	ParseItem
 	GetNames() []string
+	Parse(lex *Lexer) ([]*Node, error)
 }
 
 type ContainerItem struct {
@@ -20,22 +23,105 @@ type ContainerItem struct {
 	Name              string
 	DeprecatedMessage string
 	Properties        []ContainerChild
-	Multi             bool
-	Required          bool
-	BooleanContainer  bool
+	// Constraints are checked after Value has been parsed successfully.
+	Constraints      []Constraint
+	Multi            bool
+	Required         bool
+	BooleanContainer bool
+	// Documentation is shown when hovering over the label.
+	Documentation Documentation
 }
 
-var _ ParseItem = (*ContainerItem)(nil)
+var _ ContainerChild = (*ContainerItem)(nil)
 
 func (c ContainerItem) GetNames() []string { return []string{c.Name} }
 
-func (c ContainerItem) Parse(lex *Lexer) (interface{}, error) {
+// Lookup returns the direct property with the given label, looking inside Either() alternatives.
+func (c ContainerItem) Lookup(label string) (ContainerItem, bool) {
+	for _, prop := range c.Properties {
+		for _, item := range flattenChild(prop) {
+			if item.Name != "" && strings.EqualFold(strings.TrimSuffix(item.Name, ":"), strings.TrimSuffix(label, ":")) {
+				return item, true
+			}
+		}
+	}
+
+	return ContainerItem{}, false
+}
+
+// Labels returns the labelled properties of c in schema order, including the alternatives of
+// Either() items.
+func (c ContainerItem) Labels() []ContainerItem {
+	result := make([]ContainerItem, 0, len(c.Properties))
+	for _, prop := range c.Properties {
+		for _, item := range flattenChild(prop) {
+			if item.Name != "" {
+				result = append(result, item)
+			}
+		}
+	}
+
+	return result
+}
+
+// ValueType returns the type of the value following the label. For sections like $Name, that's
+// the value of their unlabelled first property. It's nil for labels without a value.
+func (c ContainerItem) ValueType() ParseItem {
+	if c.Value != nil {
+		return c.Value
+	}
+
+	if len(c.Properties) > 0 {
+		if item, ok := c.Properties[0].(ContainerItem); ok && item.Name == "" {
+			return item.Value
+		}
+	}
+
+	return nil
+}
+
+// ParseTable parses each item of schema in order. Errors are reported to the lexer and parsing
+// continues with the next item. Only a canceled context stops parsing early.
+func ParseTable(lex *Lexer, schema []ContainerItem) ([]*Node, error) {
+	root := ContainerItem{Properties: make([]ContainerChild, len(schema))}
+	for idx, item := range schema {
+		root.Properties[idx] = item
+	}
+
+	lex.scopes = append(lex.scopes, newScope(root))
+	defer func() {
+		lex.scopes = lex.scopes[:len(lex.scopes)-1]
+	}()
+
+	result := make([]*Node, 0)
+	for _, item := range schema {
+		if lex.ctx.Err() != nil {
+			return nil, lex.ctx.Err()
+		}
+
+		nodes, err := item.Parse(lex)
+		if err != nil {
+			lex.Report(err)
+			continue
+		}
+
+		result = append(result, nodes...)
+	}
+
+	if lex.ctx.Err() != nil {
+		return nil, lex.ctx.Err()
+	}
+
+	return result, nil
+}
+
+func (c ContainerItem) Parse(lex *Lexer) ([]*Node, error) {
 	if lex.ctx.Err() != nil {
 		return nil, lex.ctx.Err()
 	}
 
 	if c.Multi {
-		result := make([]interface{}, 0)
+		result := make([]*Node, 0)
 		required := c.Required
 		for {
 			item, err := c.ParseOne(lex, required)
@@ -56,22 +142,34 @@ func (c ContainerItem) Parse(lex *Lexer) (interface{}, error) {
 		return result, nil
 	}
 
-	return c.ParseOne(lex, c.Required)
+	node, err := c.ParseOne(lex, c.Required)
+	if err != nil || node == nil {
+		return nil, err
+	}
+
+	return []*Node{node}, nil
 }
 
-func (c ContainerItem) ParseOne(lex *Lexer, required bool) (interface{}, error) {
+func (c ContainerItem) ParseOne(lex *Lexer, required bool) (*Node, error) {
 	if c.Name == "" {
 		if c.Value == nil {
 			return nil, eris.Errorf("Encountered value item without value type %+v", c)
 		}
 
-		return c.Value.Parse(lex)
-	}
+		lex.beginSpan()
+		value, err := c.Value.Parse(lex)
+		valueRange := lex.endSpan()
+		if err != nil {
+			return nil, err
+		}
 
-	lex.PushPosition()
-	token, err := lex.Next()
-	if err != nil {
-		return nil, err
+		checkConstraints(lex, c.Constraints, value, valueRange)
+		return &Node{
+			Kind:       ValueNode,
+			Value:      value,
+			Range:      valueRange,
+			ValueRange: valueRange,
+		}, nil
 	}
 
 	var tt TokenType
@@ -86,63 +184,124 @@ func (c ContainerItem) ParseOne(lex *Lexer, required bool) (interface{}, error)
 		return nil, eris.Errorf("Invalid container name %s", c.Name)
 	}
 
+	// Labels never include the colon, even if the schema does
+	label := strings.TrimSuffix(c.Name[1:], ":")
+	lex.PushPosition()
+	token, err := lex.Next()
+	if err != nil {
+		lex.PopPosition()
+		// A missing optional label at the end of the file isn't an error
+		if !required && errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
 	if required {
 		if token.Type != tt {
 			lex.PopPosition()
 			return nil, token.Errorf("Unexpected token %v. Expected %v", token.Type, tt)
 		}
 
-		if !strings.EqualFold(token.Content, c.Name[1:]) {
+		if !strings.EqualFold(token.Content, label) {
 			lex.PopPosition()
 			return nil, token.Errorf("Unexpected label %s. Expected %s", token.Content, c.Name)
 		}
-	} else {
-		if token.Type != tt || !strings.EqualFold(token.Content, c.Name[1:]) {
-			lex.PopPosition()
-			return nil, nil
-		}
-
-		if c.DeprecatedMessage != "" {
-			lex.DropPosition()
-			return nil, token.Errorf("%s", c.DeprecatedMessage)
-		}
+	} else if token.Type != tt || !strings.EqualFold(token.Content, label) {
+		lex.PopPosition()
+		return nil, nil
 	}
 	lex.DropPosition()
+	lex.addScopeInfo(token, ScopeInfo{HoverText: c.HoverText()})
+
+	if !required && c.DeprecatedMessage != "" {
+		return nil, token.Errorf("%s", c.DeprecatedMessage)
+	}
+
+	node := &Node{
+		Kind:  SectionNode,
+		Label: c.Name,
+	}
+	// Include the label's prefix in the range
+	node.Range[0] = token.Location[0]
+	node.Range[1] = token.Location[1] - 1
 
 	if c.Value != nil {
-		return c.Value.Parse(lex)
+		lex.beginSpan()
+		node.Value, err = c.Value.Parse(lex)
+		node.ValueRange = lex.endSpan()
+		if err != nil {
+			return nil, err
+		}
+
+		checkConstraints(lex, c.Constraints, node.Value, node.ValueRange)
+		node.Kind = PropertyNode
+		node.finish(lex)
+		return node, nil
+	}
+
+	if len(c.Properties) == 0 {
+		node.Kind = PropertyNode
+		node.finish(lex)
+		return node, nil
 	}
 
 	if c.BooleanContainer {
+		lex.beginSpan()
 		enabled, err := BooleanValue.Parse(lex)
+		node.ValueRange = lex.endSpan()
 		if err != nil {
 			return nil, err
 		}
 
+		node.Value = enabled
 		if !enabled.(bool) {
-			return nil, nil
+			node.finish(lex)
+			return node, nil
 		}
 	}
 
-	knownFields := make(map[string]bool)
-	for _, prop := range c.Properties {
-		for _, name := range prop.GetNames() {
-			knownFields[strings.ToLower(name)] = true
-		}
-	}
+	current := newScope(c)
+	knownFields := current.fields
+	lex.scopes = append(lex.scopes, current)
+	defer func() {
+		lex.scopes = lex.scopes[:len(lex.scopes)-1]
+	}()
 
 	singlesSeen := make(map[string]bool)
-	result := make(map[string]interface{})
-	for _, prop := range c.Properties {
+	resumed := make(map[[2]int]bool)
+	parsed := make([]parsedLabel, 0)
+	for idx := 0; idx <= len(c.Properties); idx++ {
+		if idx == len(c.Properties) {
+			// Check what follows the last property; anything that doesn't belong to a parent
+			// is an error.
+			next, ok := c.checkEnd(lex, knownFields)
+			if ok {
+				next, ok = lex.checkOrder(next, parsed, singlesSeen)
+			}
+			if !ok || resumed[lex.Position()] {
+				break
+			}
+
+			resumed[lex.Position()] = true
+			idx = next
+		}
+
+		if lex.ctx.Err() != nil {
+			return nil, lex.ctx.Err()
+		}
+
+		prop := c.Properties[idx]
 		var token Token
 		lex.PushPosition()
 		for {
 			token, err = lex.Next()
-			// spew.Dump(token)
 			if err == nil && token.GetLabel() != "" && singlesSeen[token.GetLabel()] {
 				lex.Report(token.Errorf("Duplicate property %s", token.GetLabel()))
-				lex.readLine()
-				lex.Next()
+				if next, ok := lex.resync(token.Location[0]); ok {
+					idx = next
+					prop = c.Properties[idx]
+				}
 				lex.DropPosition()
 				lex.PushPosition()
 			} else {
@@ -151,56 +310,309 @@ func (c ContainerItem) ParseOne(lex *Lexer, required bool) (interface{}, error)
 		}
 		lex.PopPosition()
 
-		val, err := prop.Parse(lex)
+		children, err := prop.Parse(lex)
 		if err != nil {
+			if lex.ctx.Err() != nil {
+				return nil, lex.ctx.Err()
+			}
+
 			lex.Report(err)
-			// If we're not at the start of a new line, skip the rest of the current line
-			if lex.col > 0 {
-				lex.readLine()
+			errLine := lex.Position()[0]
+			if errInfo, ok := eris.Cause(err).(ParserError); ok {
+				errLine = errInfo.Location()[0]
+			}
+
+			if next, ok := lex.resync(errLine); ok {
+				if next, ok = lex.checkOrder(next, parsed, singlesSeen); ok {
+					idx = next - 1
+					continue
+				}
 			}
 
-			// Skip any fields
+			// Let the loop end and the parent handle whatever comes next
+			idx = len(c.Properties) - 1
 			continue
 		}
 
-		if val != nil {
-			if _, isSlice := val.([]interface{}); !isSlice {
+		if len(children) > 0 {
+			if !isMulti(prop) {
 				singlesSeen[token.GetLabel()] = true
 			}
 
+			if token.GetLabel() != "" {
+				tokenRange := token.Range()
+				parsed = append(parsed, parsedLabel{
+					index: idx,
+					label: token.GetLabel(),
+					// Include the label's prefix
+					location: [4]int{tokenRange[0], tokenRange[1] - 1, tokenRange[2], tokenRange[3]},
+				})
+			}
+
 			if c.DeprecatedMessage != "" {
 				lex.Report(token.Errorf("%s", c.DeprecatedMessage))
 			}
 
-			/*lex.addScopeInfo(token, ScopeInfo{
-				HoverText: fmt.Sprintf("%+v", val),
-			})*/
+			node.Children = append(node.Children, children...)
+		}
+	}
+
+	if c.Name[0] == '#' {
+		token, err := lex.Peek()
+		if err == nil && token.Type == HashEnd {
+			lex.Next()
+		} else if err == nil || errors.Is(err, io.EOF) {
+			// Keep the section; the next section (or the end of the file) is a good enough end.
+			labelRange := [4]int{node.Range[0], node.Range[1], node.Range[0], node.Range[1] + len(c.Name)}
+			lex.Report(eris.Wrap(NewParserError("Missing #End for "+c.Name, labelRange), ""))
+		} else {
+			return nil, err
+		}
+	}
+
+	node.finish(lex)
+	return node, nil
+}
+
+// checkEnd is called once all properties have been parsed. If the next label belongs to this
+// container, its index is returned. Labels of a parent container or #End end the container. In
+// all other cases, an error is reported and the parser skips ahead to the next known label.
+func (c ContainerItem) checkEnd(lex *Lexer, knownFields map[string]int) (int, bool) {
+	token, err := lex.Peek()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, false
+		}
+
+		lex.Report(err)
+		return lex.resync(lex.Position()[0])
+	}
+
+	if idx, ok := knownFields[strings.ToLower(token.GetLabel())]; ok {
+		return idx, true
+	}
+
+	if token.Type == HashEnd || token.Type == HashLabel || lex.knownToParent(token.GetLabel()) {
+		return 0, false
+	}
+
+	if token.GetLabel() != "" {
+		lex.ReportWarning(token.Errorf("%s", lex.unknownLabelMessage(token.GetLabel())))
+	} else {
+		lex.Report(token.Errorf("Unexpected value %s", token.Content))
+	}
+
+	return lex.resync(token.Location[0])
+}
+
+// parsedLabel is a property that has been parsed already.
+type parsedLabel struct {
+	index    int
+	label    string
+	location [4]int
+}
+
+// checkOrder is called before parsing the property at idx out of the schema's order (after
+// reaching the last property or recovering from an error). If a property that has to come after
+// it was parsed already, an error pointing to both is reported. Unless the lexer keeps unordered
+// properties, the property is skipped and checkOrder continues with the next known label.
+func (l *Lexer) checkOrder(idx int, parsed []parsedLabel, singlesSeen map[string]bool) (int, bool) {
+	for {
+		token, err := l.Peek()
+		if err != nil || singlesSeen[token.GetLabel()] {
+			// Duplicates are reported separately
+			return idx, true
+		}
 
-			isZero := false
-			switch val := val.(type) {
-			case string:
-				isZero = val == ""
-			case []interface{}:
-				isZero = len(val) == 0
+		var later *parsedLabel
+		for pos := range parsed {
+			if parsed[pos].index > idx {
+				later = &parsed[pos]
+				break
 			}
+		}
+
+		if later == nil {
+			return idx, true
+		}
+
+		tokenRange := token.Range()
+		location := [4]int{tokenRange[0], tokenRange[1] - 1, tokenRange[2], tokenRange[3]}
+		msg := fmt.Sprintf("%s must come before %s (line %d)", token.GetLabel(), later.label, later.location[0])
+		err = NewParserError(msg, location).WithRelated(later.label+" is here", later.location)
+		l.Report(eris.Wrap(err, ""))
+
+		if l.unordered {
+			return idx, true
+		}
+
+		var ok bool
+		idx, ok = l.resync(token.Location[0])
+		if !ok {
+			return idx, false
+		}
+	}
+}
+
+// scope contains the labels of a container that's currently being parsed.
+type scope struct {
+	item ContainerItem
+	// fields maps lower case labels to the index of the property defining them.
+	fields map[string]int
+	names  []string
+}
+
+func newScope(item ContainerItem) scope {
+	result := scope{
+		item:   item,
+		fields: make(map[string]int),
+		names:  make([]string, 0),
+	}
 
-			if !isZero {
-				result[token.GetLabel()] = val
+	for idx, prop := range item.Properties {
+		for _, name := range prop.GetNames() {
+			// Labels never include the colon, even if the schema does
+			name = strings.TrimSuffix(name, ":")
+			if _, ok := result.fields[strings.ToLower(name)]; !ok && name != "" {
+				result.fields[strings.ToLower(name)] = idx
+				result.names = append(result.names, name)
 			}
 		}
 	}
 
-	if c.Name[0] == '#' {
-		token, err := lex.Next()
-		if err != nil {
-			return nil, err
+	return result
+}
+
+// unknownLabelMessage describes an unknown label, suggesting the closest label of the current
+// container and pointing out other containers in the schema that accept it.
+func (l *Lexer) unknownLabelMessage(label string) string {
+	msg := "Unknown property " + label
+	if len(l.scopes) == 0 {
+		return msg
+	}
+
+	current := l.scopes[len(l.scopes)-1]
+	if match := closestMatch(label, current.names); match != "" {
+		msg += fmt.Sprintf(", did you mean %s?", match)
+	}
+
+	paths := findLabel(l.scopes[0].item, label, nil)
+	if len(paths) > 0 {
+		hint := strings.Join(paths[0], " > ")
+		if len(paths) > 1 {
+			hint += fmt.Sprintf(" (and %d more)", len(paths)-1)
 		}
 
-		if token.Type != HashEnd {
-			// spew.Dump(result)
-			return nil, token.Errorf("Expected '#End' but found '%s'", token.Content)
+		if current.item.Name != "" {
+			msg += fmt.Sprintf(" %s is only valid in %s, not in %s.", label, hint, current.item.Name)
+		} else {
+			msg += fmt.Sprintf(" %s is only valid in %s.", label, hint)
 		}
 	}
 
-	return result, nil
+	return msg
+}
+
+// findLabel returns the paths of the containers below item that accept label.
+func findLabel(item ContainerItem, label string, path []string) [][]string {
+	result := make([][]string, 0)
+	if item.Name != "" {
+		path = append(path[:len(path):len(path)], item.Name)
+	}
+
+	for _, prop := range item.Properties {
+		for _, child := range flattenChild(prop) {
+			if strings.EqualFold(strings.TrimSuffix(child.Name, ":"), label) {
+				result = append(result, path)
+			}
+
+			result = append(result, findLabel(child, label, path)...)
+		}
+	}
+
+	return result
+}
+
+// knownToParent returns true if label belongs to one of the containers enclosing the current
+// one.
+func (l *Lexer) knownToParent(label string) bool {
+	label = strings.ToLower(label)
+	for idx := len(l.scopes) - 2; idx >= 0; idx-- {
+		if _, ok := l.scopes[idx].fields[label]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resync skips lines until it finds #End, a section or a label known to the current container
+// or one of its parents. If the label belongs to the current container, its index is returned.
+// Unknown labels on the way are reported individually; a note is reported for any other lines
+// after errLine that had to be skipped.
+func (l *Lexer) resync(errLine int) (int, bool) {
+	skipped := [4]int{}
+	lines := 0
+	defer func() {
+		if lines > 0 {
+			noun := "lines"
+			if lines == 1 {
+				noun = "line"
+			}
+
+			msg := fmt.Sprintf("%d %s skipped while looking for the next known property", lines, noun)
+			l.ReportWarning(eris.Wrap(NewParserError(msg, skipped), ""))
+		}
+	}()
+
+	for {
+		token, err := l.Peek()
+		if errors.Is(err, io.EOF) {
+			return 0, false
+		}
+
+		if err == nil {
+			label := strings.ToLower(token.GetLabel())
+			if len(l.scopes) > 0 {
+				if idx, ok := l.scopes[len(l.scopes)-1].fields[label]; ok && label != "" && token.Location[0] != errLine {
+					return idx, true
+				}
+			}
+
+			if token.Type == HashEnd || token.Type == HashLabel || (label != "" && l.knownToParent(label)) {
+				return 0, false
+			}
+
+			if label != "" && token.Location[0] != errLine {
+				// Every unknown label gets its own warning instead of being counted as skipped
+				l.ReportWarning(token.Errorf("%s", l.unknownLabelMessage(token.GetLabel())))
+				errLine = token.Location[0]
+			}
+		}
+
+		lineRange, err := l.skipLine()
+		if lineRange[0] != errLine {
+			if lines == 0 {
+				skipped = lineRange
+			}
+			skipped[2], skipped[3] = lineRange[2], lineRange[3]
+			lines++
+		}
+
+		if err != nil {
+			return 0, false
+		}
+	}
+}
+
+// finish sets the end of the node's range to the end of the last consumed character.
+func (n *Node) finish(lex *Lexer) {
+	end := lex.LastEnd()
+	n.Range[2] = end[0]
+	n.Range[3] = end[1]
+}
+
+func isMulti(child ContainerChild) bool {
+	item, ok := child.(ContainerItem)
+	return ok && item.Multi
 }
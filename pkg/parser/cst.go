@@ -0,0 +1,377 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// CSTNode is a node in the concrete syntax tree built by ParseCST. Unlike the result tree
+// produced by ContainerItem.Parse, it keeps every byte of the source: labels, values, comments,
+// whitespace and #End markers. Writing an unmodified tree reproduces the original text exactly.
+//
+// The tree only reflects the syntax: #Sections contain $Labels which contain +Labels. The
+// Content of every token is the verbatim source text.
+type CSTNode struct {
+	// Tokens contains the node's own tokens in source order, starting with its label (if any)
+	// and followed by its value and any trailing whitespace and comments.
+	Tokens   []Token
+	Children []*CSTNode
+}
+
+// LabelToken returns the token that opened this node or nil for the root node.
+func (n *CSTNode) LabelToken() *Token {
+	if len(n.Tokens) == 0 {
+		return nil
+	}
+
+	switch n.Tokens[0].Type {
+	case HashLabel, DollarLabel, PlusLabel, HashEnd:
+		return &n.Tokens[0]
+	default:
+		return nil
+	}
+}
+
+// Label returns the node's label without the trailing colon (e.g. "$Name") or an empty string
+// for the root node.
+func (n *CSTNode) Label() string {
+	token := n.LabelToken()
+	if token == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimSuffix(token.Content, ":"))
+}
+
+// Value returns the text following the label with comments removed.
+func (n *CSTNode) Value() string {
+	first := -1
+	last := -1
+	for idx, token := range n.Tokens {
+		if token.Type == Line {
+			if first == -1 {
+				first = idx
+			}
+			last = idx
+		}
+	}
+
+	if first == -1 {
+		return ""
+	}
+
+	var result strings.Builder
+	for _, token := range n.Tokens[first : last+1] {
+		if token.Type == Line || token.Type == Whitespace {
+			result.WriteString(token.Content)
+		}
+	}
+
+	return strings.TrimSpace(result.String())
+}
+
+// Walk calls cb for the node and all of its descendants in source order. If cb returns false,
+// the node's children are skipped.
+func (n *CSTNode) Walk(cb func(*CSTNode) bool) {
+	if !cb(n) {
+		return
+	}
+
+	for _, child := range n.Children {
+		child.Walk(cb)
+	}
+}
+
+// WriteTo writes the source text represented by the tree to w.
+func (n *CSTNode) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, token := range n.Tokens {
+		written, err := io.WriteString(w, token.Content)
+		total += int64(written)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	for _, child := range n.Children {
+		written, err := child.WriteTo(w)
+		total += written
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+func (n *CSTNode) String() string {
+	var result strings.Builder
+	// strings.Builder never returns errors
+	_, _ = n.WriteTo(&result)
+	return result.String()
+}
+
+type cstScanner struct {
+	src       []rune
+	pos       int
+	line      int
+	col       int
+	lineStart bool
+}
+
+// ParseCST builds a concrete syntax tree from content. It never fails on malformed input; text
+// that doesn't look like a label is kept as a value of the preceding label.
+func ParseCST(ctx context.Context, content string) (*CSTNode, error) {
+	s := &cstScanner{
+		src:       []rune(content),
+		lineStart: true,
+	}
+
+	root := &CSTNode{}
+	var section, entry *CSTNode
+	current := root
+
+	for s.pos < len(s.src) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		token, multiText := s.next()
+		switch token.Type {
+		case HashLabel:
+			section = &CSTNode{}
+			entry = nil
+			root.Children = append(root.Children, section)
+			current = section
+		case HashEnd:
+			current = &CSTNode{}
+			if section != nil {
+				section.Children = append(section.Children, current)
+			} else {
+				root.Children = append(root.Children, current)
+			}
+			section = nil
+			entry = nil
+		case DollarLabel:
+			entry = &CSTNode{}
+			if section != nil {
+				section.Children = append(section.Children, entry)
+			} else {
+				root.Children = append(root.Children, entry)
+			}
+			current = entry
+		case PlusLabel:
+			parent := entry
+			if parent == nil {
+				parent = section
+			}
+			if parent == nil {
+				parent = root
+			}
+
+			current = &CSTNode{}
+			parent.Children = append(parent.Children, current)
+		}
+
+		current.Tokens = append(current.Tokens, token)
+
+		if multiText > 0 {
+			// Keep the whole multi-line text including $end_multi_text in a single token to
+			// avoid treating its lines as labels.
+			if isBlank(s.peek(0)) {
+				current.Tokens = append(current.Tokens, s.emit(Whitespace, s.scanBlanks()))
+			}
+			current.Tokens = append(current.Tokens, s.emit(Line, multiText))
+		}
+	}
+
+	return root, nil
+}
+
+func isBlank(char rune) bool {
+	return char == ' ' || char == '\t' || char == '\r' || char == '\n'
+}
+
+func (s *cstScanner) peek(offset int) rune {
+	if s.pos+offset >= len(s.src) {
+		return 0
+	}
+
+	return s.src[s.pos+offset]
+}
+
+// emit turns the runes up to end into a token and advances the position.
+func (s *cstScanner) emit(tt TokenType, end int) Token {
+	token := Token{
+		Type:     tt,
+		Content:  string(s.src[s.pos:end]),
+		Location: [2]int{s.line + 1, s.col},
+	}
+
+	for _, char := range s.src[s.pos:end] {
+		if char == '\n' {
+			s.line++
+			s.col = 0
+			s.lineStart = true
+		} else {
+			s.col++
+		}
+	}
+
+	if tt != Whitespace {
+		s.lineStart = false
+	}
+
+	s.pos = end
+	return token
+}
+
+// next reads the next token. If the token is a label followed by a multi-line text, the second
+// return value is the end of that text (after $end_multi_text).
+func (s *cstScanner) next() (Token, int) {
+	char := s.peek(0)
+	switch {
+	case isBlank(char):
+		return s.emit(Whitespace, s.scanBlanks()), 0
+	case char == ';':
+		return s.emit(Comment, s.scanLineEnd()), 0
+	case (char == '/' || char == '!') && s.peek(1) == '*':
+		return s.emit(BlockComment, s.scanBlockComment()), 0
+	case s.lineStart && char == '#':
+		end := s.trimBlanks(s.scanLabel(false))
+		token := s.emit(HashLabel, end)
+		if strings.EqualFold(strings.TrimSpace(token.Content[1:]), "End") {
+			token.Type = HashEnd
+		}
+		return token, 0
+	case s.lineStart && (char == '$' || char == '+'):
+		tt := DollarLabel
+		if char == '+' {
+			tt = PlusLabel
+		}
+
+		token := s.emit(tt, s.trimBlanks(s.scanLabel(true)))
+		if strings.EqualFold(token.Content, "$end_multi_text") {
+			return token, 0
+		}
+		return token, s.scanMultiText()
+	default:
+		return s.emit(Line, s.trimBlanks(s.scanValue())), 0
+	}
+}
+
+func (s *cstScanner) scanBlanks() int {
+	end := s.pos
+	for end < len(s.src) && isBlank(s.src[end]) {
+		end++
+	}
+
+	return end
+}
+
+func (s *cstScanner) scanLineEnd() int {
+	end := s.pos
+	for end < len(s.src) && s.src[end] != '\n' {
+		end++
+	}
+
+	return end
+}
+
+// scanBlockComment returns the end of the /* */ or !* *! comment starting at the current position.
+func (s *cstScanner) scanBlockComment() int {
+	closer := s.src[s.pos]
+	end := s.pos + 2
+	for end < len(s.src) {
+		if s.src[end] == '*' && end+1 < len(s.src) && s.src[end+1] == closer {
+			return end + 2
+		}
+		end++
+	}
+
+	return end
+}
+
+func (s *cstScanner) isCommentStart(idx int) bool {
+	return s.src[idx] == ';' || ((s.src[idx] == '/' || s.src[idx] == '!') && idx+1 < len(s.src) && s.src[idx+1] == '*')
+}
+
+// scanLabel returns the end of a label starting at the current position. $ and + labels end
+// after their colon.
+func (s *cstScanner) scanLabel(colon bool) int {
+	end := s.pos + 1
+	for end < len(s.src) && s.src[end] != '\n' && !s.isCommentStart(end) {
+		end++
+		if colon && s.src[end-1] == ':' {
+			break
+		}
+	}
+
+	return end
+}
+
+// scanValue returns the end of a value on the current line, stopping at comments that aren't
+// enclosed in quotes.
+func (s *cstScanner) scanValue() int {
+	end := s.pos
+	quoted := false
+	for end < len(s.src) && s.src[end] != '\n' {
+		if s.src[end] == '"' {
+			quoted = !quoted
+		} else if !quoted && s.isCommentStart(end) {
+			break
+		}
+		end++
+	}
+
+	return end
+}
+
+// trimBlanks moves end back before any trailing blanks while keeping at least one rune.
+func (s *cstScanner) trimBlanks(end int) int {
+	for end > s.pos+1 && isBlank(s.src[end-1]) {
+		end--
+	}
+
+	return end
+}
+
+// scanMultiText checks whether the text following the current position is terminated by
+// $end_multi_text before the next $, + or # label at the start of a line. Labels inside quotes
+// are part of the text. It returns the end of the terminator or 0.
+func (s *cstScanner) scanMultiText() int {
+	const terminator = "$end_multi_text"
+
+	lineStart := false
+	quoted := false
+	for idx := s.pos; idx < len(s.src); idx++ {
+		char := s.src[idx]
+		switch {
+		case char == '\n':
+			lineStart = true
+			continue
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+		case char == '$' && s.hasPrefixFold(idx, terminator):
+			return idx + len(terminator)
+		case lineStart && (char == '$' || char == '+' || char == '#'):
+			return 0
+		}
+
+		if !isBlank(char) {
+			lineStart = false
+		}
+	}
+
+	return 0
+}
+
+func (s *cstScanner) hasPrefixFold(idx int, prefix string) bool {
+	if idx+len(prefix) > len(s.src) {
+		return false
+	}
+
+	return strings.EqualFold(string(s.src[idx:idx+len(prefix)]), prefix)
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCSTRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tree, err := ParseCST(context.Background(), string(data))
+		if err != nil {
+			t.Fatalf("%s: %+v", file, err)
+		}
+
+		if output := tree.String(); output != string(data) {
+			t.Errorf("%s: output differs from input:\n%s", file, output)
+		}
+	}
+}
+
+func TestCSTStructure(t *testing.T) {
+	data, err := os.ReadFile("testdata/roundtrip.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := ParseCST(context.Background(), string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tree.Children) != 2 {
+		t.Fatalf("expected 2 sections but found %d", len(tree.Children))
+	}
+
+	ships := tree.Children[1]
+	if ships.Label() != "#Ship Classes" {
+		t.Errorf("unexpected section label %s", ships.Label())
+	}
+
+	entry := ships.Children[0]
+	if entry.Label() != "$Name" || entry.Value() != "GTF Ulysses" {
+		t.Errorf("unexpected entry %s: %s", entry.Label(), entry.Value())
+	}
+
+	if len(entry.Children) != 1 || entry.Children[0].Label() != "+nocreate" {
+		t.Errorf("expected +nocreate to be nested below $Name")
+	}
+
+	last := ships.Children[len(ships.Children)-1]
+	if last.LabelToken() == nil || last.LabelToken().Type != HashEnd {
+		t.Errorf("expected the section to end with #End but found %s", last.Label())
+	}
+
+	var species *CSTNode
+	for _, child := range ships.Children {
+		if child.Label() == "$Species" {
+			species = child
+		}
+	}
+
+	if species == nil {
+		t.Fatal("$Species is missing")
+	}
+	if species.Value() != "Terran" {
+		t.Errorf("expected $Species to be Terran but found %q", species.Value())
+	}
+	if len(species.Children) != 1 || species.Children[0].Label() != "+Tech Description" {
+		t.Fatalf("expected +Tech Description to be nested below $Species")
+	}
+
+	description := species.Children[0].Value()
+	if !strings.HasPrefix(description, "XSTR(") || !strings.HasSuffix(description, "$end_multi_text") {
+		t.Errorf("expected +Tech Description to keep the whole multi-line text but found %q", description)
+	}
+
+	for _, child := range ships.Children {
+		if child.Label() == "+not a label inside multi text" {
+			t.Errorf("multi-line text was split into labels")
+		}
+	}
+}
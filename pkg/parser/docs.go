@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Documentation explains a property. All fields are optional.
+type Documentation struct {
+	Description string
+	// Type replaces the type derived from the value type, e.g. "ship class" instead of "string".
+	Type    string
+	Default string
+	// Since is the first engine version supporting the property. It's zero for retail properties.
+	Since Version
+}
+
+// ValueDescriber is implemented by value types that can name the kind of value they expect.
+type ValueDescriber interface {
+	Describe() string
+}
+
+func describeValue(value ParseItem) string {
+	if describer, ok := value.(ValueDescriber); ok {
+		return describer.Describe()
+	}
+
+	return "value"
+}
+
+// HoverText describes c for editors: its label, type, documentation and allowed values.
+func (c ContainerItem) HoverText() string {
+	doc := c.Documentation
+	valueType := doc.Type
+	if valueType == "" {
+		if c.BooleanContainer {
+			valueType = "boolean"
+		} else if value := c.ValueType(); value != nil {
+			valueType = describeValue(value)
+		}
+	}
+
+	header := strings.TrimSuffix(c.Name, ":")
+	if valueType != "" {
+		header += " (" + valueType + ")"
+	}
+	if c.Required {
+		header += ", required"
+	}
+
+	lines := []string{header}
+	if doc.Description != "" {
+		lines = append(lines, "", doc.Description)
+	}
+
+	details := make([]string, 0)
+	if doc.Default != "" {
+		details = append(details, "Default: "+doc.Default)
+	}
+	ranges := make([]string, 0, len(c.Constraints))
+	for _, constraint := range c.Constraints {
+		if description := constraint.Describe(); description != "" {
+			ranges = append(ranges, description)
+		}
+	}
+	if len(ranges) > 0 {
+		details = append(details, "Valid range: "+strings.Join(ranges, " and "))
+	}
+
+	var enum Enum
+	switch value := c.ValueType().(type) {
+	case Enum:
+		enum = value
+	case ValueList:
+		enum, _ = value.ValueParser.(Enum)
+	}
+	if len(enum.Allowed) > 0 {
+		details = append(details, "Allowed values: "+strings.Join(enum.Allowed, ", "))
+	}
+
+	if doc.Since != (Version{}) {
+		details = append(details, fmt.Sprintf("Available since FSO %s", doc.Since))
+	}
+	if c.DeprecatedMessage != "" {
+		details = append(details, "Deprecated: "+c.DeprecatedMessage)
+	}
+
+	if len(details) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, details...)
+	}
+
+	return strings.Join(lines, "\n")
+}
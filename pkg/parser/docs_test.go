@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestScopeInfos(t *testing.T) {
+	schema := []ContainerItem{{
+		Name: "#Ship Classes",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Rotdamp", Value: FloatValue, Documentation: Documentation{
+					Description: "Rotational damping.",
+					Default:     "0",
+					Since:       Version{3, 6, 0},
+				}, Constraints: []Constraint{NonNegative(), AtMost(10)}},
+				ContainerItem{Name: "$Class Type", Value: Enum{ValueParser: StringValue, Allowed: []string{"Fighter", "Bomber"}}},
+			},
+		}},
+	}}
+
+	const table = "#Ship Classes\n$Name: GTF Ulysses\n  $Rotdamp: 1.5\n$Class Type: Fighter\n#End\n"
+	lexer := NewLexer(context.Background(), strings.NewReader(table))
+	if _, err := ParseTable(lexer, schema); err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+
+	expected := []ScopeInfo{
+		{HoverText: "#Ship Classes", Start: [2]int{1, 0}, End: [2]int{1, 13}},
+		{HoverText: "$Name (string)", Start: [2]int{2, 0}, End: [2]int{2, 5}},
+		{
+			HoverText: "$Rotdamp (float)\n\nRotational damping.\n\nDefault: 0\nValid range: at least 0 and at most 10\nAvailable since FSO 3.6.0",
+			Start:     [2]int{3, 2},
+			End:       [2]int{3, 10},
+		},
+		{HoverText: "$Class Type (string)\n\nAllowed values: Fighter, Bomber", Start: [2]int{4, 0}, End: [2]int{4, 11}},
+	}
+
+	infos := lexer.ScopeInfos()
+	if len(infos) != len(expected) {
+		t.Fatalf("expected %d scope infos but got %d: %+v", len(expected), len(infos), infos)
+	}
+
+	for idx, info := range infos {
+		if info != expected[idx] {
+			t.Errorf("scope info %d: expected %+v but got %+v", idx, expected[idx], info)
+		}
+	}
+}
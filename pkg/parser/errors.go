@@ -6,6 +6,10 @@ type ParserError struct {
 	parent   error
 	message  string
 	location [4]int
+	// related points at a second location that's relevant for the error (e.g. the label that
+	// should have come later).
+	related        [4]int
+	relatedMessage string
 }
 
 var _ error = (*ParserError)(nil)
@@ -23,3 +27,14 @@ func (e ParserError) Error() string {
 }
 
 func (e ParserError) Location() [4]int { return e.location }
+
+// WithRelated returns a copy of the error pointing to a second location.
+func (e ParserError) WithRelated(msg string, location [4]int) ParserError {
+	e.relatedMessage = msg
+	e.related = location
+	return e
+}
+
+// Related returns the second location set with WithRelated. The message is empty if there is
+// none.
+func (e ParserError) Related() (string, [4]int) { return e.relatedMessage, e.related }
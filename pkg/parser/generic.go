@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// ParseGeneric builds a result tree without a schema. It's used for tables that don't have a
+// schema yet: #Sections contain $Labels which contain the +Labels following them. Values are
+// inferred from their text: numbers become int or float64, parenthesised lists []interface{},
+// three comma separated numbers a vec3d ([]float64), XSTR("...", id) an XSTR and everything else
+// a string.
+//
+// WithEngineVersion activates version gated lines just like it does for the Lexer.
+//
+// Labels with nested labels become SectionNodes; their value is stored in a ValueNode child just
+// like the schema based parser does for entries like $Name.
+func ParseGeneric(ctx context.Context, content string, opts ...LexerOption) ([]*Node, error) {
+	config := lexerConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.engineVersion != nil {
+		content, _, _ = ApplyVersionComments(content, *config.engineVersion)
+	}
+
+	root, err := ParseCST(ctx, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return genericChildren(root), nil
+}
+
+func genericChildren(parent *CSTNode) []*Node {
+	result := make([]*Node, 0, len(parent.Children))
+	for _, child := range parent.Children {
+		node := genericNode(child)
+		if node != nil {
+			result = append(result, node)
+		}
+	}
+
+	return result
+}
+
+func genericNode(cst *CSTNode) *Node {
+	label := cst.LabelToken()
+	if label == nil || label.Type == HashEnd {
+		return nil
+	}
+
+	node := &Node{
+		Kind:     PropertyNode,
+		Label:    cst.Label(),
+		Children: genericChildren(cst),
+	}
+
+	end := cstEnd(cst)
+	node.Range = [4]int{label.Location[0], label.Location[1], end[0], end[1]}
+
+	var value interface{}
+	first, last := -1, -1
+	for idx, token := range cst.Tokens {
+		if token.Type == Line {
+			if first == -1 {
+				first = idx
+			}
+			last = idx
+		}
+	}
+
+	if first != -1 {
+		start := cst.Tokens[first].Range()
+		end := cst.Tokens[last].Range()
+		node.ValueRange = [4]int{start[0], start[1], end[2], end[3]}
+		value = inferValue(cst.Value())
+	}
+
+	if label.Type == HashLabel || len(node.Children) > 0 {
+		node.Kind = SectionNode
+		if value != nil {
+			node.Children = append([]*Node{{
+				Kind:       ValueNode,
+				Value:      value,
+				Range:      node.ValueRange,
+				ValueRange: node.ValueRange,
+			}}, node.Children...)
+		}
+	} else {
+		node.Value = value
+	}
+
+	return node
+}
+
+// cstEnd returns the position after the last label or value in cst and its descendants.
+func cstEnd(cst *CSTNode) [2]int {
+	for idx := len(cst.Children) - 1; idx >= 0; idx-- {
+		if cst.Children[idx].LabelToken() != nil {
+			return cstEnd(cst.Children[idx])
+		}
+	}
+
+	for idx := len(cst.Tokens) - 1; idx >= 0; idx-- {
+		switch cst.Tokens[idx].Type {
+		case Whitespace, Comment, BlockComment:
+			continue
+		}
+
+		tokenRange := cst.Tokens[idx].Range()
+		return [2]int{tokenRange[2], tokenRange[3]}
+	}
+
+	return [2]int{0, 0}
+}
+
+func inferValue(text string) interface{} {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	const terminator = "$end_multi_text"
+	if len(text) >= len(terminator) && strings.EqualFold(text[len(text)-len(terminator):], terminator) {
+		text = strings.TrimSpace(text[:len(text)-len(terminator)])
+		if isXSTR(text) {
+			if value, err := parseXSTR(text); err == nil {
+				return value
+			}
+		}
+		return text
+	}
+
+	if isXSTR(text) {
+		if value, err := parseXSTR(text); err == nil {
+			return value
+		}
+		return text
+	}
+
+	if text[0] == '(' && text[len(text)-1] == ')' {
+		items, _ := inferList(text, 1)
+		return items
+	}
+
+	if len(text) > 1 && text[0] == '"' && text[len(text)-1] == '"' && !strings.Contains(text[1:len(text)-1], "\"") {
+		return text[1 : len(text)-1]
+	}
+
+	if number, ok := inferNumber(text); ok {
+		return number
+	}
+
+	parts := strings.FieldsFunc(text, func(char rune) bool {
+		return char == ',' || isBlank(char)
+	})
+	if len(parts) > 1 {
+		numbers := make([]interface{}, len(parts))
+		for idx, part := range parts {
+			number, ok := inferNumber(part)
+			if !ok {
+				return text
+			}
+			numbers[idx] = number
+		}
+
+		if len(numbers) == 3 {
+			vec := make([]float64, 3)
+			for idx, number := range numbers {
+				if value, ok := number.(int); ok {
+					vec[idx] = float64(value)
+				} else {
+					vec[idx] = number.(float64)
+				}
+			}
+			return vec
+		}
+
+		return numbers
+	}
+
+	return text
+}
+
+// inferList parses the list starting at text[pos] (after the opening parenthesis) and returns
+// its items and the position after the closing parenthesis.
+func inferList(text string, pos int) ([]interface{}, int) {
+	result := make([]interface{}, 0)
+	for pos < len(text) {
+		char := text[pos]
+		switch {
+		case char == ')':
+			return result, pos + 1
+		case char == ',' || isBlank(rune(char)):
+			pos++
+		case char == '(':
+			var items []interface{}
+			items, pos = inferList(text, pos+1)
+			result = append(result, items)
+		case char == '"':
+			end := strings.IndexByte(text[pos+1:], '"')
+			if end == -1 {
+				result = append(result, text[pos+1:])
+				return result, len(text)
+			}
+
+			result = append(result, text[pos+1:pos+1+end])
+			pos += end + 2
+		default:
+			end := pos
+			for end < len(text) && !strings.ContainsRune(",()\" \t\r\n", rune(text[end])) {
+				end++
+			}
+
+			word := text[pos:end]
+			if number, ok := inferNumber(word); ok {
+				result = append(result, number)
+			} else {
+				result = append(result, word)
+			}
+			pos = end
+		}
+	}
+
+	return result, pos
+}
+
+func inferNumber(text string) (interface{}, bool) {
+	if text == "" || !strings.ContainsRune("0123456789-+.", rune(text[0])) {
+		return nil, false
+	}
+
+	if value, err := strconv.Atoi(text); err == nil {
+		return value, true
+	}
+
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return value, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseGeneric(t *testing.T) {
+	const table = `#AI Profiles
+$Profile Name: FS2 RETAIL
+$Flags: ( "smart shield management" ("nested" 2) )
++Scale: 5, 3, 2, 1.5
+$Offset: 1, 2, 3
+$Text: Hello
+world
+$end_multi_text
+#Objects
+$Name: foo
+`
+
+	nodes, err := ParseGeneric(context.Background(), table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 2 || nodes[0].Label != "#AI Profiles" || nodes[1].Label != "#Objects" {
+		t.Fatalf("unexpected sections: %+v", nodes)
+	}
+
+	profiles := nodes[0]
+	if value := profiles.Child("$Profile Name").Value; value != "FS2 RETAIL" {
+		t.Errorf("unexpected name %#v", value)
+	}
+
+	flags := profiles.Child("$Flags")
+	if flags.Kind != SectionNode {
+		t.Errorf("$Flags should contain +Scale but is a %s", flags.Kind)
+	}
+
+	expected := []interface{}{"smart shield management", []interface{}{"nested", 2}}
+	if !reflect.DeepEqual(flags.InlineValue(), expected) {
+		t.Errorf("unexpected flags %#v", flags.InlineValue())
+	}
+
+	if value := flags.Child("+Scale").Value; !reflect.DeepEqual(value, []interface{}{5, 3, 2, 1.5}) {
+		t.Errorf("unexpected scale %#v", value)
+	}
+
+	if value := profiles.Child("$Offset").Value; !reflect.DeepEqual(value, []float64{1, 2, 3}) {
+		t.Errorf("unexpected vec3d %#v", value)
+	}
+
+	if value := profiles.Child("$Text").Value; value != "Hello\nworld" {
+		t.Errorf("unexpected text %#v", value)
+	}
+}
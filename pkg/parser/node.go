@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"strings"
+)
+
+type NodeKind uint8
+
+const (
+	// SectionNode is a label with nested properties (#Ship Classes, $Name, $Subsystem, ...).
+	SectionNode NodeKind = iota + 1
+	// PropertyNode is a label followed by a single value ($Density: 1, +Tech Title: ...).
+	PropertyNode
+	// ValueNode is a value without its own label, like the ship name following $Name.
+	ValueNode
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case SectionNode:
+		return "section"
+	case PropertyNode:
+		return "property"
+	case ValueNode:
+		return "value"
+	default:
+		return "unknown"
+	}
+}
+
+func (k NodeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Node is a single entry in the result tree produced by ContainerItem.Parse.
+//
+// Ranges use the same convention as Token.Range(): lines start at 1 and columns at 0.
+type Node struct {
+	Kind       NodeKind    `json:"kind"`
+	Label      string      `json:"label,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+	Range      [4]int      `json:"range"`
+	ValueRange [4]int      `json:"valueRange"`
+	Children   []*Node     `json:"children,omitempty"`
+}
+
+// Child returns the first direct child with the given label or nil if there is none.
+func (n *Node) Child(label string) *Node {
+	for _, child := range n.Children {
+		if strings.EqualFold(child.Label, label) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// ChildrenNamed returns all direct children with the given label in source order.
+func (n *Node) ChildrenNamed(label string) []*Node {
+	result := make([]*Node, 0)
+	for _, child := range n.Children {
+		if strings.EqualFold(child.Label, label) {
+			result = append(result, child)
+		}
+	}
+
+	return result
+}
+
+// InlineValue returns the value written on the node's label line. For sections like $Name
+// that's the value of their first unlabelled child.
+func (n *Node) InlineValue() interface{} {
+	if n.Value != nil {
+		return n.Value
+	}
+
+	if len(n.Children) > 0 && n.Children[0].Kind == ValueNode {
+		return n.Children[0].Value
+	}
+
+	return nil
+}
+
+// InlineValueRange returns the source range of the value returned by InlineValue.
+func (n *Node) InlineValueRange() [4]int {
+	if n.Value == nil && len(n.Children) > 0 && n.Children[0].Kind == ValueNode {
+		return n.Children[0].ValueRange
+	}
+
+	return n.ValueRange
+}
+
+// Clone returns a deep copy of the node and its children. Values are shared.
+func (n *Node) Clone() *Node {
+	result := *n
+	if n.Children != nil {
+		result.Children = make([]*Node, len(n.Children))
+		for idx, child := range n.Children {
+			result.Children[idx] = child.Clone()
+		}
+	}
+
+	return &result
+}
+
+// Walk calls cb for the node and all of its descendants in source order. If cb returns false,
+// the node's children are skipped.
+func (n *Node) Walk(cb func(*Node) bool) {
+	if !cb(n) {
+		return
+	}
+
+	for _, child := range n.Children {
+		child.Walk(cb)
+	}
+}
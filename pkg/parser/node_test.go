@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func parseNodeTable(t *testing.T) []*Node {
+	schema := []ContainerItem{{
+		Name: "#Weapons",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Damage", Value: IntegerValue},
+				ContainerItem{
+					Name:             "$Homing",
+					BooleanContainer: true,
+					Properties: []ContainerChild{
+						ContainerItem{Name: "+Type", Value: StringValue},
+					},
+				},
+			},
+		}},
+	}}
+
+	const table = `#Weapons
+$Name: Subach HL-7
+$Damage: 15
+$Homing: NO
+$Name: Harpoon
+$Homing: YES
++Type: HEAT
+#End
+`
+
+	nodes, err := ParseTable(NewLexer(context.Background(), strings.NewReader(table)), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return nodes
+}
+
+func TestNodeInlineValue(t *testing.T) {
+	entries := parseNodeTable(t)[0].ChildrenNamed("$Name")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries but got %d", len(entries))
+	}
+
+	// Sections take their inline value from the first unlabelled child
+	if value := entries[0].InlineValue(); value != "Subach HL-7" {
+		t.Errorf("unexpected inline value %#v", value)
+	}
+	if valueRange := entries[0].InlineValueRange(); valueRange != [4]int{2, 7, 2, 18} {
+		t.Errorf("unexpected inline value range %v", valueRange)
+	}
+
+	damage := entries[0].Child("$damage")
+	if damage.InlineValue() != 15 || damage.InlineValueRange() != [4]int{3, 9, 3, 11} {
+		t.Errorf("unexpected damage %#v", damage)
+	}
+
+	// Boolean containers set to NO are kept with a false value
+	homing := entries[0].Child("$Homing")
+	if homing == nil || homing.Value != false || len(homing.Children) != 0 {
+		t.Fatalf("expected a disabled $Homing node but got %#v", homing)
+	}
+	if homing.ValueRange != [4]int{4, 9, 4, 11} {
+		t.Errorf("unexpected $Homing value range %v", homing.ValueRange)
+	}
+
+	homing = entries[1].Child("$Homing")
+	if homing == nil || homing.Value != true || homing.Child("+Type") == nil {
+		t.Errorf("expected an enabled $Homing node with +Type but got %#v", homing)
+	}
+}
+
+func TestNodeClone(t *testing.T) {
+	original := parseNodeTable(t)[0]
+	clone := original.Clone()
+
+	entry := clone.ChildrenNamed("$Name")[0]
+	entry.Children[0].Value = "Subach HL-9"
+	entry.Children = append(entry.Children, &Node{Kind: PropertyNode, Label: "$Mass"})
+
+	entry = original.ChildrenNamed("$Name")[0]
+	if entry.InlineValue() != "Subach HL-7" || entry.Child("$Mass") != nil {
+		t.Errorf("changing the clone modified the original")
+	}
+}
+
+func TestNodeWalk(t *testing.T) {
+	labels := make([]string, 0)
+	parseNodeTable(t)[0].Walk(func(node *Node) bool {
+		if node.Kind != ValueNode {
+			labels = append(labels, node.Label)
+		}
+		// Skip the properties of enabled boolean containers
+		return node.Label != "$Homing"
+	})
+
+	expected := "#Weapons $Name $Damage $Homing $Name $Homing"
+	if result := strings.Join(labels, " "); result != expected {
+		t.Errorf("expected %q but got %q", expected, result)
+	}
+}
@@ -0,0 +1,237 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rotisserie/eris"
+)
+
+func TestErrorRecovery(t *testing.T) {
+	schema := []ContainerItem{{
+		Name: "#Ship Classes",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Density", Value: IntegerValue},
+				ContainerItem{Name: "$Mass", Value: IntegerValue},
+				ContainerItem{Name: "$Speed", Value: IntegerValue},
+			},
+		}},
+	}, {
+		Name:       "#Weapons",
+		Properties: []ContainerChild{ContainerItem{Name: "$Name", Value: StringValue, Multi: true}},
+	}}
+
+	const table = `#Ship Classes
+$Name: GTF Ulysses
+$Density: 1
+$Mas: 5
+this line
+  and this one
+$Speed: 3
+$Name: GTF Hercules
+$Density: x
+$Mass: 2
+#weapons
+$Name: Subach HL-7
+#end
+`
+
+	lexer := NewLexer(context.Background(), strings.NewReader(table))
+	nodes, err := ParseTable(lexer, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locations := func(errs []error) []string {
+		result := make([]string, len(errs))
+		for idx, err := range errs {
+			parseErr, ok := eris.Cause(err).(ParserError)
+			if !ok {
+				t.Fatalf("unexpected error %+v", err)
+			}
+			result[idx] = parseErr.Error()
+		}
+		return result
+	}
+
+	errs := locations(lexer.Errors())
+	if len(errs) != 2 || !strings.HasPrefix(errs[1], "Missing #End for #Ship Classes") {
+		t.Errorf("unexpected errors %q", errs)
+	}
+
+	warnings := lexer.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("expected a warning and a note but got %v", warnings)
+	}
+
+	if msg := warnings[0].Error(); !strings.HasPrefix(msg, "Unknown property $Mas, did you mean $Mass? at 4:") {
+		t.Errorf("unexpected warning %q", msg)
+	}
+
+	note := eris.Cause(warnings[1]).(ParserError)
+	if !strings.HasPrefix(note.Error(), "2 lines skipped") || note.Location() != [4]int{5, 0, 6, 14} {
+		t.Errorf("unexpected note %q at %v", note.Error(), note.Location())
+	}
+
+	if len(nodes) != 2 {
+		t.Fatalf("expected both sections but got %d", len(nodes))
+	}
+
+	ships := nodes[0].ChildrenNamed("$Name")
+	if len(ships) != 2 {
+		t.Fatalf("expected 2 ships but got %d", len(ships))
+	}
+
+	if speed := ships[0].Child("$Speed"); speed == nil || speed.Value != 3 {
+		t.Errorf("expected $Speed to be parsed after recovering, got %#v", speed)
+	}
+
+	if mass := ships[1].Child("$Mass"); mass == nil || mass.Value != 2 {
+		t.Errorf("expected $Mass to be parsed after the bad value, got %#v", mass)
+	}
+
+	if weapons := nodes[1].ChildrenNamed("$Name"); len(weapons) != 1 {
+		t.Errorf("unexpected weapons %#v", weapons)
+	}
+}
+
+func TestUnknownLabelHints(t *testing.T) {
+	schema := []ContainerItem{{
+		Name: "#Ship Classes",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Hitpoints", Value: IntegerValue},
+				ContainerItem{
+					Name:  "$Subsystem",
+					Value: StringValue,
+					Multi: true,
+					Properties: []ContainerChild{
+						ContainerItem{Name: "$Alt Subsystem Name", Value: StringValue},
+					},
+				},
+			},
+		}},
+	}}
+
+	const table = `#Ship Classes
+$Name: GTF Ulysses
+$Hitpoint: 100
+$Alt Subsystem Name: Engine
+#End
+`
+
+	lexer := NewLexer(context.Background(), strings.NewReader(table))
+	if _, err := ParseTable(lexer, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := lexer.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings but got %v", warnings)
+	}
+
+	if msg := warnings[0].Error(); !strings.Contains(msg, "did you mean $Hitpoints?") {
+		t.Errorf("missing suggestion in %q", msg)
+	}
+
+	if msg := warnings[1].Error(); !strings.Contains(msg, "$Alt Subsystem Name is only valid in #Ship Classes > $Name > $Subsystem, not in $Name") {
+		t.Errorf("missing hint in %q", msg)
+	}
+
+	if len(lexer.Errors()) != 0 {
+		t.Errorf("unexpected errors %v", lexer.Errors())
+	}
+}
+
+func TestSchemaLabelWithColon(t *testing.T) {
+	schema := []ContainerItem{{
+		Name: "#Ship Classes",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Cockpit offset:", Value: Vec3dValue},
+			},
+		}},
+	}}
+
+	lexer := NewLexer(context.Background(), strings.NewReader("#Ship Classes\n$Name: GTF Ulysses\n$Cockpit offset: 1 2 3\n#End\n"))
+	if _, err := ParseTable(lexer, schema); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, warning := range lexer.Warnings() {
+		if strings.Contains(warning.Error(), "Unknown property") {
+			t.Errorf("unexpected warning %v", warning)
+		}
+	}
+}
+
+func TestOutOfOrderLabels(t *testing.T) {
+	schema := []ContainerItem{{
+		Name: "#Ship Classes",
+		Properties: []ContainerChild{ContainerItem{
+			Name:  "$Name",
+			Multi: true,
+			Properties: []ContainerChild{
+				ContainerItem{Name: "", Value: StringValue, Required: true},
+				ContainerItem{Name: "$Hitpoints", Value: IntegerValue},
+				ContainerItem{Name: "$Subsystem Repair Rate", Value: IntegerValue},
+				ContainerItem{Name: "$Speed", Value: IntegerValue},
+			},
+		}},
+	}}
+
+	const table = `#Ship Classes
+$Name: GTF Ulysses
+$Subsystem Repair Rate: 1
+$Hitpoints: 100
+$Speed: 5
+#End
+`
+
+	for _, unordered := range []bool{false, true} {
+		opts := []LexerOption{}
+		if unordered {
+			opts = append(opts, WithUnorderedProperties())
+		}
+
+		lexer := NewLexer(context.Background(), strings.NewReader(table), opts...)
+		nodes, err := ParseTable(lexer, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errs := lexer.Errors()
+		if len(errs) != 1 {
+			t.Fatalf("expected one error but got %v", errs)
+		}
+
+		parseErr := eris.Cause(errs[0]).(ParserError)
+		if parseErr.Error() != "$Hitpoints must come before $Subsystem Repair Rate (line 3) at 4:0" {
+			t.Errorf("unexpected error %q", parseErr.Error())
+		}
+
+		if msg, related := parseErr.Related(); msg == "" || related != [4]int{3, 0, 3, 22} {
+			t.Errorf("unexpected related location %q %v", msg, related)
+		}
+
+		ship := nodes[0].Children[0]
+		if ship.Child("$Speed") == nil {
+			t.Errorf("expected $Speed to be parsed")
+		}
+
+		if hitpoints := ship.Child("$Hitpoints"); (hitpoints != nil) != unordered {
+			t.Errorf("unordered = %v but got $Hitpoints %#v", unordered, hitpoints)
+		}
+	}
+}
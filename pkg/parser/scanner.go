@@ -22,6 +22,7 @@ const (
 	Comment
 	BlockComment
 	HashEnd
+	Whitespace
 )
 
 type Token struct {
@@ -65,11 +66,21 @@ type Scanner interface {
 }
 
 type savedPos struct {
-	stream int64
-	line   int
-	col    int
+	stream  int64
+	line    int
+	col     int
+	lastEnd [2]int
+	spans   []span
 }
 
+// span tracks the first and last non-blank character consumed while it's active.
+type span struct {
+	start   [2]int
+	end     [2]int
+	started bool
+}
+
+// ScopeInfo is the hover text for a range of the input.
 type ScopeInfo struct {
 	HoverText string
 	Start     [2]int
@@ -84,21 +95,127 @@ type Lexer struct {
 	warnings   []error
 	scopeInfos []ScopeInfo
 	posStack   []savedPos
+	spans      []span
+	lastEnd    [2]int
 	line       int
 	col        int
+
+	versionRegions []VersionRegion
+	// scopes contains the labels known to each container that's currently being parsed, from
+	// the outermost to the innermost one.
+	scopes []scope
+	// unordered keeps out of order properties instead of skipping them.
+	unordered bool
+}
+
+// LexerOption configures optional Lexer features.
+type LexerOption func(*lexerConfig)
+
+type lexerConfig struct {
+	engineVersion       *Version
+	unorderedProperties bool
+}
+
+// WithUnorderedProperties keeps parsing properties that appear out of order as if they were in
+// the right place. They're still reported as errors since the engine would ignore them.
+func WithUnorderedProperties() LexerOption {
+	return func(config *lexerConfig) {
+		config.unorderedProperties = true
+	}
 }
 
-func NewLexer(ctx context.Context, buffer Scanner) *Lexer {
-	return &Lexer{ctx: ctx, buffer: buffer}
+func NewLexer(ctx context.Context, buffer Scanner, opts ...LexerOption) *Lexer {
+	config := lexerConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	lexer := &Lexer{ctx: ctx, unordered: config.unorderedProperties}
+	content, err := readAllRunes(buffer)
+	if err != nil {
+		lexer.Report(eris.Wrap(err, "failed to read input"))
+	}
+
+	if config.engineVersion != nil {
+		content, lexer.versionRegions, lexer.warnings = ApplyVersionComments(content, *config.engineVersion)
+	}
+
+	// Comments are removed before tokenizing just like the engine does it. This way, values never
+	// have to deal with them.
+	content, errors := StripComments(content)
+	lexer.errors = append(lexer.errors, errors...)
+	lexer.buffer = strings.NewReader(content)
+
+	return lexer
 }
 
 func (l *Lexer) errorf(msg string, args ...interface{}) error {
 	return eris.Wrap(NewParserError(fmt.Sprintf(msg, args...), [4]int{l.line + 1, l.col - 1, l.line + 1, l.col}), "")
 }
 
+// advance updates the current position after char has been consumed.
+func (l *Lexer) advance(char rune) {
+	if char == '\n' {
+		l.line++
+		l.col = 0
+		return
+	}
+
+	l.col++
+	if char == ' ' || char == '\t' || char == '\r' {
+		return
+	}
+
+	l.lastEnd = [2]int{l.line + 1, l.col}
+	for idx := range l.spans {
+		span := &l.spans[idx]
+		if !span.started {
+			span.start = [2]int{l.line + 1, l.col - 1}
+			span.started = true
+		}
+		span.end = l.lastEnd
+	}
+}
+
+// Position returns the current line (starting at 1) and column (starting at 0).
+func (l *Lexer) Position() [2]int {
+	return [2]int{l.line + 1, l.col}
+}
+
+// LastEnd returns the position right after the last non-blank character that was consumed.
+func (l *Lexer) LastEnd() [2]int {
+	return l.lastEnd
+}
+
+// beginSpan starts tracking a new range. Spans can be nested; every call has to be followed by
+// a call to endSpan.
+func (l *Lexer) beginSpan() {
+	l.spans = append(l.spans, span{})
+}
+
+// endSpan returns the range covering every non-blank character consumed since the matching
+// beginSpan.
+func (l *Lexer) endSpan() [4]int {
+	var result span
+	if len(l.spans) > 0 {
+		result = l.spans[len(l.spans)-1]
+		l.spans = l.spans[:len(l.spans)-1]
+	}
+
+	if !result.started {
+		pos := l.Position()
+		return [4]int{pos[0], pos[1], pos[0], pos[1]}
+	}
+	return [4]int{result.start[0], result.start[1], result.end[0], result.end[1]}
+}
+
 func (l *Lexer) addScopeInfo(token Token, info ScopeInfo) {
 	codeRange := token.Range()
 	info.Start = [2]int{codeRange[0], codeRange[1]}
+	if token.GetLabel() != "" {
+		// Include the label's prefix
+		info.Start[1]--
+	}
 	info.End = [2]int{codeRange[2], codeRange[3]}
 
 	l.scopeInfos = append(l.scopeInfos, info)
@@ -132,9 +249,11 @@ func (l *Lexer) PushPosition() {
 	}
 
 	l.posStack = append(l.posStack, savedPos{
-		stream: streamPos,
-		line:   l.line,
-		col:    l.col,
+		stream:  streamPos,
+		line:    l.line,
+		col:     l.col,
+		lastEnd: l.lastEnd,
+		spans:   append([]span(nil), l.spans...),
 	})
 }
 
@@ -149,6 +268,8 @@ func (l *Lexer) PopPosition() {
 	}
 	l.line = frame.line
 	l.col = frame.col
+	l.lastEnd = frame.lastEnd
+	l.spans = frame.spans
 }
 
 func (l *Lexer) DropPosition() {
@@ -164,13 +285,6 @@ func (l *Lexer) Next() (Token, error) {
 		}
 	}
 
-	for l.next.Type == Comment {
-		err := l.readToken()
-		if err != nil {
-			return Token{}, err
-		}
-	}
-
 	result := l.next
 	l.next = nil
 	return *result, nil
@@ -187,7 +301,7 @@ func (l *Lexer) ReadMultilineText(end string) (string, error) {
 			return "", err
 		}
 		if char == ':' {
-			l.col++
+			l.advance(char)
 			if err = l.skipWhitespace(); err != nil {
 				return "", err
 			}
@@ -205,12 +319,7 @@ func (l *Lexer) ReadMultilineText(end string) (string, error) {
 			return "", err
 		}
 
-		if char == '\n' {
-			l.line++
-			l.col = 0
-		} else {
-			l.col++
-		}
+		l.advance(char)
 
 		if endpos > -1 {
 			if string(char) == end[endpos:endpos+size] {
@@ -257,49 +366,37 @@ func (l *Lexer) readToken() error {
 	l.PushPosition()
 	char, _, err := l.buffer.ReadRune()
 	if err != nil {
+		l.DropPosition()
 		return err
 	}
-	l.col++
 
 	switch char {
 	case '#':
+		l.advance(char)
 		err = l.readHashLabel()
 	case '$':
+		l.advance(char)
 		err = l.readSimpleLabel(DollarLabel)
 	case '+':
+		l.advance(char)
 		err = l.readSimpleLabel(PlusLabel)
 	case '"':
+		l.advance(char)
 		err = l.readString()
 	case '-', '.', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		l.col--
 		if err = l.buffer.UnreadRune(); err == nil {
 			err = l.readNumber()
 		}
-	case ';':
-		err = l.readLineComment()
-	case '/':
-		char, _, err = l.buffer.ReadRune()
-		if err == nil {
-			if char == '*' {
-				l.col++
-				err = l.readBlockComment()
-			}
-			if err == nil {
-				l.buffer.UnreadRune()
-				err = l.errorf("Unrecognised token %s", string(char))
-			}
-		}
 	case ' ', '\t', '\r', '\n':
 		err = l.buffer.UnreadRune()
 		if err == nil {
-			l.col--
-
 			err = l.skipWhitespace()
 			if err == nil {
 				err = l.readToken()
 			}
 		}
 	default:
+		l.advance(char)
 		l.DropPosition()
 		return l.errorf("Unrecognised token %s", string(char))
 	}
@@ -328,17 +425,11 @@ func (l *Lexer) skipWhitespace() error {
 			return err
 		}
 
-		if char == '\n' {
-			l.line++
-			l.col = 0
-			continue
-		}
-
-		if char != ' ' && char != '\t' && char != '\r' {
+		if char != ' ' && char != '\t' && char != '\r' && char != '\n' {
 			return l.buffer.UnreadRune()
 		}
 
-		l.col++
+		l.advance(char)
 	}
 }
 
@@ -362,12 +453,7 @@ func (l *Lexer) readUntil(stopchars string) (string, error) {
 			return string(result), nil
 		}
 
-		if char == '\n' {
-			l.line++
-			l.col = 0
-		} else {
-			l.col++
-		}
+		l.advance(char)
 		result = append(result, char)
 	}
 }
@@ -392,12 +478,7 @@ func (l *Lexer) readOnly(allowchars string) (string, error) {
 			return result, nil
 		}
 
-		if char == '\n' {
-			l.line++
-			l.col = 0
-		} else {
-			l.col++
-		}
+		l.advance(char)
 		result += string(char)
 	}
 }
@@ -408,12 +489,7 @@ func (l *Lexer) requireRune(r rune) error {
 		return err
 	}
 
-	if char == '\n' {
-		l.line++
-		l.col = 0
-	} else {
-		l.col++
-	}
+	l.advance(char)
 
 	if char != r {
 		return l.errorf("Expected '%s' but found '%s'", string(r), string(char))
@@ -436,12 +512,7 @@ func (l *Lexer) optionalRune(r rune) (bool, error) {
 		return false, nil
 	}
 
-	if char == '\n' {
-		l.line++
-		l.col = 0
-	} else {
-		l.col++
-	}
+	l.advance(char)
 
 	return true, nil
 }
@@ -455,7 +526,7 @@ func (l *Lexer) readHashLabel() error {
 	}
 
 	label = strings.Trim(label, " ")
-	if label == "End" {
+	if strings.EqualFold(label, "End") {
 		l.next.Type = HashEnd
 	}
 	l.next.Content = label
@@ -475,64 +546,37 @@ func (l *Lexer) readSimpleLabel(tt TokenType) error {
 	return err
 }
 
-func (l *Lexer) readLineComment() error {
-	l.makeToken(Comment)
-	content, err := l.readUntil("\n")
-	if err != nil {
-		l.next = nil
-		return err
-	}
+// Peek returns the next token without consuming it.
+func (l *Lexer) Peek() (Token, error) {
+	l.PushPosition()
+	token, err := l.Next()
+	l.PopPosition()
 
-	l.next.Content = content
-	return nil
+	return token, err
 }
 
-func (l *Lexer) readBlockComment() error {
-	l.makeToken(BlockComment)
-	result := ""
-
-	for {
-		content, err := l.readUntil("*")
-		if err != nil {
-			l.next = nil
-			return err
-		}
-
-		result += content
-
-		// Skip the asterisk
-		_, _, err = l.buffer.ReadRune()
-		if err != nil {
-			l.next = nil
-			return err
-		}
-		l.col++
-
-		char, _, err := l.buffer.ReadRune()
-		if err != nil {
-			l.next = nil
-			return err
-		}
-		if char == '/' {
-			l.col++
-			break
-		}
+// skipLine skips blanks and the rest of the line following them. It returns the range of the
+// skipped text.
+func (l *Lexer) skipLine() ([4]int, error) {
+	l.next = nil
+	if err := l.skipWhitespace(); err != nil {
+		return [4]int{}, err
+	}
 
-		result += "*"
-		err = l.buffer.UnreadRune()
-		if err != nil {
-			l.next = nil
-			return err
-		}
+	l.beginSpan()
+	_, err := l.readUntil("\n")
+	skipped := l.endSpan()
+	if err != nil {
+		return skipped, err
 	}
 
-	l.next.Content = result
-	return nil
+	_, err = l.optionalRune('\n')
+	return skipped, err
 }
 
 func (l *Lexer) readLine() error {
 	l.makeToken(Line)
-	content, err := l.readUntil(";\r\n")
+	content, err := l.readUntil("\r\n")
 	if err != nil {
 		l.next = nil
 		return err
@@ -620,12 +664,12 @@ func (l *Lexer) ReadList(cb func() error) error {
 		}
 
 		if char == ')' {
-			l.col++
+			l.advance(char)
 			break
 		}
 
 		if char == ',' {
-			l.col++
+			l.advance(char)
 			continue
 		}
 
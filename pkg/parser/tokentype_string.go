@@ -17,11 +17,12 @@ func _() {
 	_ = x[Comment-7]
 	_ = x[BlockComment-8]
 	_ = x[HashEnd-9]
+	_ = x[Whitespace-10]
 }
 
-const _TokenType_name = "HashLabelDollarLabelPlusLabelLineStringNumberCommentBlockCommentHashEnd"
+const _TokenType_name = "HashLabelDollarLabelPlusLabelLineStringNumberCommentBlockCommentHashEndWhitespace"
 
-var _TokenType_index = [...]uint8{0, 9, 20, 29, 33, 39, 45, 52, 64, 71}
+var _TokenType_index = [...]uint8{0, 9, 20, 29, 33, 39, 45, 52, 64, 71, 81}
 
 func (i TokenType) String() string {
 	i -= 1
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// Enum wraps a string value type and reports a warning if the parsed value isn't one of
+// Allowed. Like FSO, the comparison ignores case. Allowed values ending in "*" match any value
+// starting with the text before the "*" (e.g. "Spawn *" matches "Spawn Fury,5").
+type Enum struct {
+	ValueParser ParseItem
+	Allowed     []string
+}
+
+var _ ParseItem = (*Enum)(nil)
+
+func (e Enum) Parse(lex *Lexer) (interface{}, error) {
+	lex.beginSpan()
+	value, err := e.ValueParser.Parse(lex)
+	valueRange := lex.endSpan()
+	if err != nil {
+		return nil, err
+	}
+
+	str, ok := value.(string)
+	if ok && !e.Matches(str) {
+		msg := fmt.Sprintf("Unknown value \"%s\"", str)
+		if match := closestMatch(str, e.Allowed); match != "" {
+			msg += fmt.Sprintf(", did you mean \"%s\"?", match)
+		}
+
+		lex.ReportWarning(eris.Wrap(NewParserError(msg, valueRange), ""))
+	}
+
+	return value, nil
+}
+
+func (e Enum) Format(value interface{}) (string, error) {
+	formatter, ok := e.ValueParser.(ValueFormatter)
+	if !ok {
+		return "", eris.Errorf("Value type %T can't be formatted", e.ValueParser)
+	}
+
+	return formatter.Format(value)
+}
+
+func (e Enum) Describe() string {
+	return describeValue(e.ValueParser)
+}
+
+// Matches returns true if value is one of the allowed values.
+func (e Enum) Matches(value string) bool {
+	for _, allowed := range e.Allowed {
+		if strings.HasSuffix(allowed, "*") {
+			prefix := allowed[:len(allowed)-1]
+			if len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix) {
+				return true
+			}
+		} else if strings.EqualFold(value, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// closestMatch returns the candidate with the smallest edit distance to value or an empty
+// string if none of them is similar enough to be a plausible typo.
+func closestMatch(value string, candidates []string) string {
+	value = strings.ToLower(value)
+	best := ""
+	bestDistance := -1
+	for _, candidate := range candidates {
+		distance := levenshtein(value, strings.ToLower(strings.TrimSuffix(candidate, "*")))
+		if bestDistance == -1 || distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	// Anything that requires changing more than half of the value is not a typo.
+	if bestDistance == -1 || bestDistance*2 > len([]rune(value)) {
+		return ""
+	}
+
+	return best
+}
+
+func levenshtein(a, b string) int {
+	runesA := []rune(a)
+	runesB := []rune(b)
+
+	prev := make([]int, len(runesB)+1)
+	cur := make([]int, len(runesB)+1)
+	for idx := range prev {
+		prev[idx] = idx
+	}
+
+	for i := 1; i <= len(runesA); i++ {
+		cur[0] = i
+		for j := 1; j <= len(runesB); j++ {
+			cost := 1
+			if runesA[i-1] == runesB[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(minInt(cur[j-1]+1, prev[j]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(runesB)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rotisserie/eris"
+)
+
+func TestEnumWarnings(t *testing.T) {
+	item := ContainerItem{
+		Name: "$Flags",
+		Value: ValueList{
+			ValueParser: Enum{
+				ValueParser: StringFlag,
+				Allowed:     []string{"fire on target", "Spawn *"},
+			},
+		},
+	}
+
+	lexer := NewLexer(context.Background(), strings.NewReader(`$Flags: ( "Fire On Target" "spawn Fury,5" "fire on targt" )
+#End
+`))
+	node, err := item.ParseOne(lexer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(node.Value.([]interface{})) != 3 {
+		t.Errorf("expected all flags to be kept but got %#v", node.Value)
+	}
+
+	warnings := lexer.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning but got %v", warnings)
+	}
+
+	parseErr, ok := eris.Cause(warnings[0]).(ParserError)
+	if !ok {
+		t.Fatalf("unexpected warning %#v", warnings[0])
+	}
+
+	if !strings.Contains(parseErr.Error(), `did you mean "fire on target"`) {
+		t.Errorf("missing suggestion in %q", parseErr.Error())
+	}
+
+	if loc := parseErr.Location(); loc != [4]int{1, 42, 1, 57} {
+		t.Errorf("unexpected warning location %v", loc)
+	}
+}
+
+func TestListSizes(t *testing.T) {
+	item := ContainerItem{
+		Name: "$Detail distance",
+		Value: ValueList{
+			ValueParser: IntegerValue,
+			MinSize:     4,
+			MaxSize:     4,
+		},
+	}
+
+	lexer := NewLexer(context.Background(), strings.NewReader("$Detail distance: (0, 80, 300)\n#End\n"))
+	node, err := item.ParseOne(lexer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(node.Value.([]interface{})) != 3 {
+		t.Errorf("expected the list to be kept but got %#v", node.Value)
+	}
+
+	errors := lexer.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected one error but got %v", errors)
+	}
+
+	parseErr := eris.Cause(errors[0]).(ParserError)
+	if loc := parseErr.Location(); loc != [4]int{1, 18, 1, 30} {
+		t.Errorf("unexpected error location %v", loc)
+	}
+
+	fixed := ContainerItem{
+		Name: "+Position",
+		Value: FixedList{
+			ValueParser: FloatValue,
+			Size:        3,
+		},
+	}
+
+	lexer = NewLexer(context.Background(), strings.NewReader("+Position: 1, 2.5 3\n+Position: 1, 2\n#End\n"))
+	node, err = fixed.ParseOne(lexer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(node.Value.([]interface{})) != 3 {
+		t.Errorf("unexpected value %#v", node.Value)
+	}
+
+	_, err = fixed.ParseOne(lexer, true)
+	if err == nil || !strings.Contains(err.Error(), "Expected 3 values but found 2") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	item := ContainerItem{
+		Name:        "$Detail distance",
+		Value:       ValueList{ValueParser: IntegerValue},
+		Constraints: []Constraint{NonNegative(), Ascending()},
+	}
+
+	lexer := NewLexer(context.Background(), strings.NewReader("$Detail distance: (0, 300, 80, -1)\n#End\n"))
+	if _, err := item.ParseOne(lexer, true); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings := lexer.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("expected two warnings but got %v", warnings)
+	}
+
+	for _, warning := range warnings {
+		parseErr := eris.Cause(warning).(ParserError)
+		if loc := parseErr.Location(); loc != [4]int{1, 18, 1, 34} {
+			t.Errorf("unexpected location %v for %v", loc, parseErr)
+		}
+	}
+}
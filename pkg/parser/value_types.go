@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/rotisserie/eris"
 )
 
 type SwitchItem struct {
@@ -20,28 +23,33 @@ func (i SwitchItem) GetNames() []string {
 	return names
 }
 
-func (i *SwitchItem) Parse(lex *Lexer) (interface{}, error) {
-	var value interface{}
+func (i *SwitchItem) Parse(lex *Lexer) ([]*Node, error) {
+	var nodes []*Node
 	var err error
 
 	for _, item := range i.Items {
-		value, err = item.Parse(lex)
-		if err == nil && value != nil {
-			return value, nil
+		nodes, err = item.Parse(lex)
+		if err == nil && len(nodes) > 0 {
+			return nodes, nil
 		}
 	}
 
 	return nil, err
 }
 
+// ValueList is a list of values enclosed in parentheses.
 type ValueList struct {
 	ValueParser ParseItem
+	// MinSize and MaxSize limit the number of items in the list. Zero means no limit.
+	MinSize int
+	MaxSize int
 }
 
 var _ ParseItem = (*ValueList)(nil)
 
 func (i ValueList) Parse(lex *Lexer) (interface{}, error) {
 	result := make([]interface{}, 0)
+	lex.beginSpan()
 	err := lex.ReadList(func() error {
 		value, err := i.ValueParser.Parse(lex)
 		if err != nil {
@@ -51,13 +59,67 @@ func (i ValueList) Parse(lex *Lexer) (interface{}, error) {
 		result = append(result, value)
 		return nil
 	})
+	listRange := lex.endSpan()
 	if err != nil {
 		return nil, err
 	}
 
+	// A list with the wrong size is still usable so we only report the problem.
+	if msg := i.checkSize(len(result)); msg != "" {
+		lex.Report(eris.Wrap(NewParserError(msg, listRange), ""))
+	}
+
 	return result, nil
 }
 
+func (i ValueList) checkSize(size int) string {
+	switch {
+	case i.MinSize > 0 && i.MinSize == i.MaxSize && size != i.MinSize:
+		return fmt.Sprintf("Expected %d values but found %d", i.MinSize, size)
+	case i.MinSize > 0 && size < i.MinSize:
+		return fmt.Sprintf("Expected at least %d values but found %d", i.MinSize, size)
+	case i.MaxSize > 0 && size > i.MaxSize:
+		return fmt.Sprintf("Expected at most %d values but found %d", i.MaxSize, size)
+	default:
+		return ""
+	}
+}
+
+func (i ValueList) Format(value interface{}) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return "", eris.Errorf("Expected a list but got %T", value)
+	}
+
+	parts, err := formatItems(i.ValueParser, items)
+	if err != nil {
+		return "", err
+	}
+
+	if len(parts) == 0 {
+		return "( )", nil
+	}
+	return "( " + strings.Join(parts, " ") + " )", nil
+}
+
+func (i ValueList) Describe() string {
+	result := "list of " + describeValue(i.ValueParser) + " values"
+	switch {
+	case i.MinSize > 0 && i.MinSize == i.MaxSize:
+		result = fmt.Sprintf("list of %d %s values", i.MinSize, describeValue(i.ValueParser))
+	case i.MinSize > 0 && i.MaxSize > 0:
+		result += fmt.Sprintf(" (%d to %d)", i.MinSize, i.MaxSize)
+	case i.MinSize > 0:
+		result += fmt.Sprintf(" (at least %d)", i.MinSize)
+	case i.MaxSize > 0:
+		result += fmt.Sprintf(" (at most %d)", i.MaxSize)
+	}
+
+	return result
+}
+
+// FixedList is a list of exactly Size values without parentheses. The values can optionally be
+// separated by commas.
 type FixedList struct {
 	ValueParser ParseItem
 	Size        int
@@ -67,33 +129,133 @@ var _ ParseItem = (*FixedList)(nil)
 
 func (i FixedList) Parse(lex *Lexer) (interface{}, error) {
 	result := make([]interface{}, i.Size)
+	lex.beginSpan()
 	for idx := range result {
-		value, err := i.ValueParser.Parse(lex)
+		// Rewind on errors to avoid consuming the following label if the list is too short
+		lex.PushPosition()
+		value, err := i.parseItem(lex, idx)
 		if err != nil {
-			return nil, err
+			lex.PopPosition()
+			listRange := lex.endSpan()
+			if idx == 0 {
+				return nil, err
+			}
+
+			return nil, eris.Wrap(NewParserError(fmt.Sprintf("Expected %d values but found %d", i.Size, idx), listRange), "")
 		}
+		lex.DropPosition()
 
 		result[idx] = value
 	}
+	lex.endSpan()
 
 	return result, nil
 }
 
+func (i FixedList) parseItem(lex *Lexer, idx int) (interface{}, error) {
+	if idx > 0 {
+		if err := lex.skipWhitespace(); err != nil {
+			return nil, err
+		}
+
+		if _, err := lex.optionalRune(','); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.ValueParser.Parse(lex)
+}
+
+func (i FixedList) Format(value interface{}) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return "", eris.Errorf("Expected a list but got %T", value)
+	}
+
+	if len(items) != i.Size {
+		return "", eris.Errorf("Expected %d values but got %d", i.Size, len(items))
+	}
+
+	parts, err := formatItems(i.ValueParser, items)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func (i FixedList) Describe() string {
+	return fmt.Sprintf("%d %s values", i.Size, describeValue(i.ValueParser))
+}
+
+func formatItems(valueType ParseItem, items []interface{}) ([]string, error) {
+	formatter, ok := valueType.(ValueFormatter)
+	if !ok {
+		return nil, eris.Errorf("Value type %T can't be formatted", valueType)
+	}
+
+	parts := make([]string, len(items))
+	for idx, item := range items {
+		part, err := formatter.Format(item)
+		if err != nil {
+			return nil, err
+		}
+
+		parts[idx] = part
+	}
+
+	return parts, nil
+}
+
 type (
 	parseHandler     func(*Lexer) (interface{}, error)
+	formatHandler    func(interface{}) (string, error)
 	genericValueType struct {
-		handler parseHandler
+		name      string
+		handler   parseHandler
+		formatter formatHandler
 	}
 )
 
-var _ ParseItem = (*genericValueType)(nil)
+var (
+	_ ParseItem      = (*genericValueType)(nil)
+	_ ValueFormatter = (*genericValueType)(nil)
+)
 
 func (g genericValueType) Parse(lex *Lexer) (interface{}, error) {
 	return g.handler(lex)
 }
 
-func newGenericValueType(handler parseHandler) genericValueType {
-	return genericValueType{handler: handler}
+func (g genericValueType) Format(value interface{}) (string, error) {
+	return g.formatter(value)
+}
+
+func (g genericValueType) Describe() string {
+	return g.name
+}
+
+func newGenericValueType(name string, handler parseHandler, formatter formatHandler) genericValueType {
+	return genericValueType{name: name, handler: handler, formatter: formatter}
+}
+
+func formatString(value interface{}) (string, error) {
+	str, ok := value.(string)
+	if !ok {
+		return "", eris.Errorf("Expected a string but got %T", value)
+	}
+
+	return str, nil
+}
+
+func formatFloat(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(value), nil
+	default:
+		return "", eris.Errorf("Expected a float but got %T", value)
+	}
 }
 
 func consumeValue(lex *Lexer) (Token, error) {
@@ -112,7 +274,7 @@ func consumeValue(lex *Lexer) (Token, error) {
 	return token, nil
 }
 
-var StringValue = newGenericValueType(func(lex *Lexer) (interface{}, error) {
+var StringValue = newGenericValueType("string", func(lex *Lexer) (interface{}, error) {
 	// Force the lexer to read a line
 	err := lex.readLine()
 	if err != nil {
@@ -125,11 +287,18 @@ var StringValue = newGenericValueType(func(lex *Lexer) (interface{}, error) {
 	}
 
 	result := strings.Trim(token.Content, " \n\t")
-	// TODO: Parse XSTR?
 	return result, nil
-})
+}, formatString)
+
+var StringFlag = newGenericValueType("string", func(lex *Lexer) (interface{}, error) {
+	if err := lex.skipWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if err := lex.requireRune('"'); err != nil {
+		return nil, err
+	}
 
-var StringFlag = newGenericValueType(func(lex *Lexer) (interface{}, error) {
 	// Force the lexer to read a string
 	err := lex.readString()
 	if err != nil {
@@ -143,9 +312,16 @@ var StringFlag = newGenericValueType(func(lex *Lexer) (interface{}, error) {
 
 	result := strings.Trim(token.Content, " \n\t")
 	return result, nil
+}, func(value interface{}) (string, error) {
+	str, err := formatString(value)
+	if err != nil {
+		return "", err
+	}
+
+	return "\"" + str + "\"", nil
 })
 
-var WordValue = newGenericValueType(func(lex *Lexer) (interface{}, error) {
+var WordValue = newGenericValueType("word", func(lex *Lexer) (interface{}, error) {
 	// Force the lexer to read a Word
 	err := lex.readWord()
 	if err != nil {
@@ -158,18 +334,25 @@ var WordValue = newGenericValueType(func(lex *Lexer) (interface{}, error) {
 	}
 
 	return token.Content, nil
-})
+}, formatString)
 
-var MultilineStringValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var MultilineStringValue = newGenericValueType("multiline text", func(l *Lexer) (interface{}, error) {
 	result, err := l.ReadMultilineText("$end_multi_text")
 	if err != nil {
 		return nil, err
 	}
 
 	return strings.Trim(result, " \n\t"), nil
+}, func(value interface{}) (string, error) {
+	str, err := formatString(value)
+	if err != nil {
+		return "", err
+	}
+
+	return str + "\n$end_multi_text", nil
 })
 
-var BooleanValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var BooleanValue = newGenericValueType("boolean", func(l *Lexer) (interface{}, error) {
 	// Force the lexer to read a word
 	err := l.readWord()
 	if err != nil {
@@ -189,9 +372,19 @@ var BooleanValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 	default:
 		return nil, token.Errorf("Expected boolean but found %s", token.Content)
 	}
+}, func(value interface{}) (string, error) {
+	enabled, ok := value.(bool)
+	if !ok {
+		return "", eris.Errorf("Expected a boolean but got %T", value)
+	}
+
+	if enabled {
+		return "YES", nil
+	}
+	return "NO", nil
 })
 
-var FloatValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var FloatValue = newGenericValueType("float", func(l *Lexer) (interface{}, error) {
 	if err := l.skipWhitespace(); err != nil {
 		return nil, err
 	}
@@ -212,9 +405,9 @@ var FloatValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 	}
 
 	return value, nil
-})
+}, formatFloat)
 
-var IntegerValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var IntegerValue = newGenericValueType("integer", func(l *Lexer) (interface{}, error) {
 	if err := l.skipWhitespace(); err != nil {
 		return nil, err
 	}
@@ -235,14 +428,42 @@ var IntegerValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 	}
 
 	return value, nil
+}, func(value interface{}) (string, error) {
+	number, ok := value.(int)
+	if !ok {
+		return "", eris.Errorf("Expected an integer but got %T", value)
+	}
+
+	return strconv.Itoa(number), nil
 })
 
-var FlagValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+// flagValue is the value of labels that don't have one. It's a separate type so IsFlag can
+// recognise it.
+type flagValue struct{}
+
+func (flagValue) Parse(lex *Lexer) (interface{}, error) {
 	// If we've come this far, the flag is present.
 	return true, nil
-})
+}
+
+func (flagValue) Format(value interface{}) (string, error) {
+	// Flags don't have a value, only their label is written.
+	return "", nil
+}
+
+func (flagValue) Describe() string {
+	return "flag"
+}
 
-var Vec3dValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var FlagValue ParseItem = flagValue{}
+
+// IsFlag returns true if value is FlagValue.
+func IsFlag(value ParseItem) bool {
+	_, ok := value.(flagValue)
+	return ok
+}
+
+var Vec3dValue = newGenericValueType("vector", func(l *Lexer) (interface{}, error) {
 	result := []float64{0, 0, 0}
 	for idx := range result {
 		if err := l.skipWhitespace(); err != nil {
@@ -272,9 +493,21 @@ var Vec3dValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 		result[idx] = value
 	}
 	return result, nil
+}, func(value interface{}) (string, error) {
+	vec, ok := value.([]float64)
+	if !ok || len(vec) != 3 {
+		return "", eris.Errorf("Expected a vec3d but got %T", value)
+	}
+
+	parts := make([]string, len(vec))
+	for idx, part := range vec {
+		parts[idx], _ = formatFloat(part)
+	}
+
+	return strings.Join(parts, ", "), nil
 })
 
-var ColorValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var ColorValue = newGenericValueType("color", func(l *Lexer) (interface{}, error) {
 	// Force the lexer to read a line
 	err := l.readLine()
 	if err != nil {
@@ -286,7 +519,9 @@ var ColorValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 		return nil, err
 	}
 
-	parts := strings.Split(token.Content, " ")
+	parts := strings.FieldsFunc(token.Content, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t'
+	})
 	if len(parts) != 3 {
 		return nil, token.Errorf("Expected vec3d but found %d parts", len(parts))
 	}
@@ -311,6 +546,13 @@ var ColorValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 	}
 
 	return []int{a, b, c}, nil
+}, func(value interface{}) (string, error) {
+	color, ok := value.([]int)
+	if !ok || len(color) != 3 {
+		return "", eris.Errorf("Expected a color but got %T", value)
+	}
+
+	return strconv.Itoa(color[0]) + " " + strconv.Itoa(color[1]) + " " + strconv.Itoa(color[2]), nil
 })
 
 type Subsystem struct {
@@ -319,7 +561,7 @@ type Subsystem struct {
 	TurnRate   float64
 }
 
-var SubsystemValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var SubsystemValue = newGenericValueType("subsystem", func(l *Lexer) (interface{}, error) {
 	data, err := l.readUntil(",\n")
 	if err != nil {
 		return nil, err
@@ -381,10 +623,25 @@ var SubsystemValue = newGenericValueType(func(l *Lexer) (interface{}, error) {
 		return nil, token.Errorf("Failed to parse turn rate %s (%s)", token.Content, err)
 	}
 
+	return result, nil
+}, func(value interface{}) (string, error) {
+	subsys, ok := value.(Subsystem)
+	if !ok {
+		return "", eris.Errorf("Expected a subsystem but got %T", value)
+	}
+
+	result := subsys.Name
+	if subsys.HitPercent != 0 || subsys.TurnRate != 0 {
+		result += ", " + strconv.FormatFloat(subsys.HitPercent, 'f', -1, 64)
+	}
+	if subsys.TurnRate != 0 {
+		result += ", " + strconv.FormatFloat(subsys.TurnRate, 'f', -1, 64)
+	}
+
 	return result, nil
 })
 
-var WeaponBankList = newGenericValueType(func(l *Lexer) (interface{}, error) {
+var WeaponBankList = newGenericValueType("weapon bank list", func(l *Lexer) (interface{}, error) {
 	banks := make([][]string, 0, 2)
 	for {
 		if err := l.skipWhitespace(); err != nil {
@@ -434,4 +691,20 @@ var WeaponBankList = newGenericValueType(func(l *Lexer) (interface{}, error) {
 	}
 
 	return banks, nil
+}, func(value interface{}) (string, error) {
+	banks, ok := value.([][]string)
+	if !ok {
+		return "", eris.Errorf("Expected a list of weapon banks but got %T", value)
+	}
+
+	parts := make([]string, len(banks))
+	for idx, bank := range banks {
+		parts[idx] = "("
+		for _, weapon := range bank {
+			parts[idx] += " \"" + weapon + "\""
+		}
+		parts[idx] += " )"
+	}
+
+	return strings.Join(parts, " "), nil
 })
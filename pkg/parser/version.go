@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// Version is an engine version like 3.7.4 or 23.2.0. Missing components are 0.
+type Version [4]int
+
+// LatestVersion is newer than every released engine version. With it, every ;;FSO x.y.z;; line is
+// active and every ;;!FSO x.y.z;; line inactive.
+var LatestVersion = Version{1 << 30, 0, 0, 0}
+
+// ParseVersion parses a version with up to four dot separated components.
+func ParseVersion(text string) (Version, error) {
+	var result Version
+	parts := strings.Split(strings.TrimSpace(text), ".")
+	if len(parts) > len(result) {
+		return result, eris.Errorf("Version %s has too many components", text)
+	}
+
+	for idx, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return result, eris.Errorf("Invalid version %s", text)
+		}
+		result[idx] = number
+	}
+
+	return result, nil
+}
+
+// Compare returns -1, 0 or 1 if v is older than, equal to or newer than other.
+func (v Version) Compare(other Version) int {
+	for idx := range v {
+		if v[idx] < other[idx] {
+			return -1
+		}
+		if v[idx] > other[idx] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func (v Version) String() string {
+	if v == LatestVersion {
+		return "latest"
+	}
+
+	result := fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+	if v[3] != 0 {
+		result += fmt.Sprintf(".%d", v[3])
+	}
+	return result
+}
+
+// VersionRegion is a line gated by a ;;FSO x.y.z;; or ;;!FSO x.y.z;; comment. Range covers the
+// marker and the rest of the line.
+type VersionRegion struct {
+	Range   [4]int
+	Version Version
+	Negated bool
+	Active  bool
+}
+
+// WithEngineVersion enables version comments: lines starting with ;;FSO x.y.z;; are parsed if
+// version is at least x.y.z and lines starting with ;;!FSO x.y.z;; if it's older. All other
+// version comments stay comments. Without this option, every version comment is a comment.
+func WithEngineVersion(version Version) LexerOption {
+	return func(config *lexerConfig) {
+		config.engineVersion = &version
+	}
+}
+
+// VersionRegions returns the version gated lines found in the input. It's empty unless the
+// lexer was created with WithEngineVersion.
+func (l *Lexer) VersionRegions() []VersionRegion {
+	return l.versionRegions
+}
+
+// readAllRunes reads the remaining content of buffer.
+func readAllRunes(buffer io.RuneScanner) (string, error) {
+	var result strings.Builder
+	for {
+		char, _, err := buffer.ReadRune()
+		if err == io.EOF {
+			return result.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		result.WriteRune(char)
+	}
+}
+
+// ApplyVersionComments activates the lines of content which are enabled for version by replacing
+// their version comment with spaces. Positions in the result match the original content. Invalid
+// version comments are returned as warnings and stay comments.
+func ApplyVersionComments(content string, version Version) (string, []VersionRegion, []error) {
+	lines := strings.Split(content, "\n")
+	regions := make([]VersionRegion, 0)
+	warnings := make([]error, 0)
+
+	var state commentState
+	for idx, line := range lines {
+		start := state.commentStart(line)
+		if start == -1 {
+			continue
+		}
+
+		comment := line[start:]
+		negated := false
+		switch {
+		case strings.HasPrefix(comment, ";;FSO "):
+			comment = comment[len(";;FSO "):]
+		case strings.HasPrefix(comment, ";;!FSO "):
+			comment = comment[len(";;!FSO "):]
+			negated = true
+		default:
+			continue
+		}
+
+		lineEnd := len(strings.TrimRight(line, "\r"))
+		end := strings.Index(comment, ";;")
+		if end == -1 {
+			warnings = append(warnings, eris.Wrap(NewParserError("Missing ;; after the version", [4]int{idx + 1, runeCol(line, start), idx + 1, runeCol(line, lineEnd)}), ""))
+			continue
+		}
+
+		markerEnd := len(line) - len(comment) + end + 2
+
+		required, err := ParseVersion(comment[:end])
+		if err != nil {
+			warnings = append(warnings, eris.Wrap(NewParserError(err.Error(), [4]int{idx + 1, runeCol(line, start), idx + 1, runeCol(line, markerEnd)}), ""))
+			continue
+		}
+
+		active := version.Compare(required) >= 0
+		if negated {
+			active = !active
+		}
+
+		regions = append(regions, VersionRegion{
+			Range:   [4]int{idx + 1, runeCol(line, start), idx + 1, runeCol(line, lineEnd)},
+			Version: required,
+			Negated: negated,
+			Active:  active,
+		})
+
+		if active {
+			lines[idx] = line[:start] + strings.Repeat(" ", markerEnd-start) + line[markerEnd:]
+			// The rest of the line is parsed now, so quotes and block comments opened there
+			// carry over to the next lines.
+			state.commentStart(line[markerEnd:])
+		}
+	}
+
+	return strings.Join(lines, "\n"), regions, warnings
+}
+
+// commentState tracks quotes and block comments across lines the same way StripComments does.
+type commentState struct {
+	quoted bool
+	// closer is the first character of the open block comment's marker (/ or !) or 0.
+	closer byte
+}
+
+// commentStart returns the byte offset of the first ; in line that is neither quoted nor inside a
+// block comment or -1. The rest of the line is a comment, so the state is only updated up to it.
+func (s *commentState) commentStart(line string) int {
+	for idx := 0; idx < len(line); idx++ {
+		char := line[idx]
+		next := byte(0)
+		if idx+1 < len(line) {
+			next = line[idx+1]
+		}
+
+		switch {
+		case s.closer != 0:
+			if char == '*' && next == s.closer {
+				s.closer = 0
+				idx++
+			}
+		case char == '"':
+			s.quoted = !s.quoted
+		case s.quoted:
+		case char == ';':
+			return idx
+		case (char == '/' || char == '!') && next == '*':
+			s.closer = char
+			idx++
+		}
+	}
+
+	return -1
+}
+
+// runeCol converts a byte offset in line to a column.
+func runeCol(line string, offset int) int {
+	return len([]rune(line[:offset]))
+}
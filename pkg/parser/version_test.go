@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVersionComments(t *testing.T) {
+	const table = `$Old: 1
+;;FSO 3.8.0;; $New: 2
+;;!FSO 3.8.0;; $Old: 3
+;;FSO 23.0;; $Newer: 4
+;;FSO 3.x;; $Broken: 5
+#End
+`
+
+	schema := []ContainerItem{
+		{Name: "$Old", Value: IntegerValue, Multi: true},
+		{Name: "$New", Value: IntegerValue},
+		{Name: "$Newer", Value: IntegerValue},
+	}
+
+	parse := func(version Version) ([]*Node, *Lexer) {
+		lexer := NewLexer(context.Background(), strings.NewReader(table), WithEngineVersion(version))
+		nodes := make([]*Node, 0)
+		for _, item := range schema {
+			result, err := item.Parse(lexer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes = append(nodes, result...)
+		}
+
+		return nodes, lexer
+	}
+
+	nodes, lexer := parse(Version{3, 7, 4})
+	if len(nodes) != 2 || nodes[0].Value != 1 || nodes[1].Value != 3 {
+		t.Errorf("unexpected result for 3.7.4: %#v", nodes)
+	}
+
+	regions := lexer.VersionRegions()
+	if len(regions) != 3 {
+		t.Fatalf("unexpected regions %#v", regions)
+	}
+
+	if regions[0].Active || !regions[1].Active || !regions[1].Negated || regions[2].Active {
+		t.Errorf("unexpected region states %#v", regions)
+	}
+
+	if regions[0].Range != [4]int{2, 0, 2, 21} {
+		t.Errorf("unexpected region range %v", regions[0].Range)
+	}
+
+	if len(lexer.Warnings()) != 1 {
+		t.Errorf("expected a warning for the invalid version but got %v", lexer.Warnings())
+	}
+
+	nodes, _ = parse(LatestVersion)
+	if len(nodes) != 3 || nodes[1].Value != 2 || nodes[1].Range != [4]int{2, 14, 2, 21} || nodes[2].Value != 4 {
+		t.Errorf("unexpected result for the latest version: %#v", nodes)
+	}
+}
+
+func TestVersionCommentsInQuotes(t *testing.T) {
+	const table = `+Description: XSTR("First line
+;;FSO 3.8.0;; is still text", -1)
+/* "unbalanced
+*/ ;;FSO 3.8.0;; $New: 2
+`
+
+	content, regions, _ := ApplyVersionComments(table, LatestVersion)
+	if len(regions) != 1 || regions[0].Range[0] != 4 {
+		t.Fatalf("unexpected regions %#v", regions)
+	}
+
+	if !strings.Contains(content, ";;FSO 3.8.0;; is still text") {
+		t.Errorf("quoted text was changed: %q", content)
+	}
+}
+
+func TestVersionCommentsOpeningQuotes(t *testing.T) {
+	tests := []string{
+		";;FSO 3.0.0;; $Foo: \"abc\n$X: def\"\n;;FSO 3.0.0;; $Bar: 1\n",
+		";;FSO 3.0.0;; $Foo: 1 /* abc\n\"def */\n;;FSO 3.0.0;; $Bar: 1\n",
+	}
+
+	for _, table := range tests {
+		content, regions, _ := ApplyVersionComments(table, Version{23, 0, 0})
+		if len(regions) != 2 || !regions[0].Active || !regions[1].Active {
+			t.Errorf("unexpected regions %#v for %q", regions, table)
+		}
+
+		if !strings.Contains(content, "\n              $Bar: 1\n") {
+			t.Errorf("the last line wasn't activated: %q", content)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// ValueFormatter is implemented by value types that can turn a parsed value back into table text.
+type ValueFormatter interface {
+	Format(value interface{}) (string, error)
+}
+
+type tableWriter struct {
+	out *bufio.Writer
+}
+
+// WriteTable writes nodes as table text to w. The schema determines the order of sections and
+// properties as well as the format of each value, so nodes may come from ContainerItem.Parse or
+// be built by hand.
+func WriteTable(w io.Writer, schema []ContainerItem, nodes []*Node) error {
+	writer := tableWriter{out: bufio.NewWriter(w)}
+
+	remaining := nodes
+	for _, item := range schema {
+		var err error
+		remaining, err = writer.writeMatching(item, remaining, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(remaining) > 0 {
+		return eris.Errorf("Found %s which is not part of the schema", remaining[0].Label)
+	}
+
+	return writer.out.Flush()
+}
+
+// writeMatching writes every node that matches item and returns the remaining nodes. If separate
+// is set, each node is followed by an empty line.
+func (w tableWriter) writeMatching(item ContainerItem, nodes []*Node, separate bool) ([]*Node, error) {
+	remaining := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Kind == ValueNode || !strings.EqualFold(node.Label, item.Name) {
+			remaining = append(remaining, node)
+			continue
+		}
+
+		if err := w.writeNode(item, node); err != nil {
+			return nil, err
+		}
+
+		if separate {
+			w.out.WriteString("\n")
+		}
+	}
+
+	return remaining, nil
+}
+
+func (w tableWriter) writeNode(item ContainerItem, node *Node) error {
+	label := strings.TrimSuffix(item.Name, ":")
+	w.out.WriteString(label)
+
+	if item.Value != nil {
+		value, err := formatValue(item.Value, node.Value)
+		if err != nil {
+			return eris.Wrapf(err, "Failed to format %s", label)
+		}
+
+		if value != "" {
+			w.out.WriteString(": " + value)
+		}
+		w.out.WriteString("\n")
+		return nil
+	}
+
+	if len(item.Properties) == 0 {
+		w.out.WriteString("\n")
+		return nil
+	}
+
+	children := node.Children
+	props := item.Properties
+
+	// Unlabelled values are written on the same line as the label
+	inline := make([]string, 0)
+	for len(props) > 0 {
+		prop, ok := props[0].(ContainerItem)
+		if !ok || prop.Name != "" || len(children) == 0 || children[0].Kind != ValueNode {
+			break
+		}
+
+		value, err := formatValue(prop.Value, children[0].Value)
+		if err != nil {
+			return eris.Wrapf(err, "Failed to format %s", label)
+		}
+
+		inline = append(inline, value)
+		props = props[1:]
+		children = children[1:]
+	}
+
+	if item.BooleanContainer {
+		enabled, _ := node.Value.(bool)
+		value, _ := BooleanValue.Format(enabled)
+		inline = append(inline, value)
+	}
+
+	if label[0] != '#' {
+		w.out.WriteString(":")
+		if len(inline) > 0 {
+			w.out.WriteString(" " + strings.Join(inline, " "))
+		}
+	}
+	w.out.WriteString("\n")
+
+	for _, prop := range props {
+		var err error
+		for _, candidate := range flattenChild(prop) {
+			if candidate.Name == "" {
+				continue
+			}
+
+			separate := label[0] == '#' && len(candidate.Properties) > 0
+			children, err = w.writeMatching(candidate, children, separate)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(children) > 0 {
+		return eris.Errorf("Found %s in %s which is not part of the schema", children[0].Label, label)
+	}
+
+	if label[0] == '#' {
+		w.out.WriteString("#End\n")
+	}
+
+	return nil
+}
+
+func formatValue(valueType ParseItem, value interface{}) (string, error) {
+	formatter, ok := valueType.(ValueFormatter)
+	if !ok {
+		return "", eris.Errorf("Value type %T can't be formatted", valueType)
+	}
+
+	return formatter.Format(value)
+}
+
+// flattenChild returns the container items a child can represent, resolving Either() alternatives.
+func flattenChild(child ContainerChild) []ContainerItem {
+	switch child := child.(type) {
+	case ContainerItem:
+		return []ContainerItem{child}
+	case *SwitchItem:
+		result := make([]ContainerItem, 0, len(child.Items))
+		for _, item := range child.Items {
+			result = append(result, flattenChild(item)...)
+		}
+		return result
+	default:
+		return nil
+	}
+}
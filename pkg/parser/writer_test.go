@@ -0,0 +1,82 @@
+package parser_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+	"github.com/ngld/fso-table-parser/pkg/structs"
+)
+
+const writerInput = `#Ship Classes
+
+$Name: GTF Ulysses
++nocreate
+$Short name: TFigA
+$Species: Terran
++Tech Description:
+A fast fighter.
+$end_multi_text
+$Detail distance: ( 0 80 300 900 )
+$Density: 1.5
+$Max Velocity: 60, 60, 62.5
+$Glide: YES
++Max Glide Speed: 40
+$Allowed PBanks: ( "Subach HL-7" "Akheton SDG" ) ( "Subach HL-7" )
+$Flags: ( "player_ship" "default_player_ship" )
+$Subsystem: communication, 5
+
+$Name: GTF Hercules
+$Species: Terran
+
+#End
+`
+
+func parseShips(t *testing.T, content string) []*parser.Node {
+	lexer := parser.NewLexer(context.Background(), strings.NewReader(content))
+	result := make([]*parser.Node, 0)
+	for _, item := range structs.NewShipsTable() {
+		nodes, err := item.Parse(lexer)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %+v", item.Name, err)
+		}
+
+		result = append(result, nodes...)
+	}
+
+	for _, err := range lexer.Errors() {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	return result
+}
+
+// values strips the source ranges so trees parsed from different text can be compared.
+func values(nodes []*parser.Node) string {
+	for _, root := range nodes {
+		root.Walk(func(node *parser.Node) bool {
+			node.Range = [4]int{}
+			node.ValueRange = [4]int{}
+			return true
+		})
+	}
+
+	data, _ := json.Marshal(nodes)
+	return string(data)
+}
+
+func TestWriteTableRoundTrip(t *testing.T) {
+	nodes := parseShips(t, writerInput)
+
+	var output strings.Builder
+	if err := parser.WriteTable(&output, structs.NewShipsTable(), nodes); err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	reparsed := parseShips(t, output.String())
+	if values(nodes) != values(reparsed) {
+		t.Errorf("Written table doesn't match the input:\n%s", output.String())
+	}
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// XSTR is a translatable string written as XSTR("text", id). An ID of -1 means that the string
+// doesn't have a translation yet.
+type XSTR struct {
+	Text string `json:"text"`
+	ID   int    `json:"id"`
+}
+
+func (x XSTR) String() string {
+	return "XSTR(\"" + x.Text + "\", " + strconv.Itoa(x.ID) + ")"
+}
+
+// isXSTR returns true if text starts with XSTR(.
+func isXSTR(text string) bool {
+	text = strings.TrimSpace(text)
+	if len(text) < 5 || !strings.EqualFold(text[:4], "XSTR") {
+		return false
+	}
+
+	return strings.HasPrefix(strings.TrimLeft(text[4:], " \t"), "(")
+}
+
+// parseXSTR parses text in the format XSTR("text", id). The text can span multiple lines.
+func parseXSTR(text string) (XSTR, error) {
+	result := XSTR{}
+	rest := strings.TrimSpace(text)[4:]
+	rest = strings.TrimLeft(rest, " \t")[1:]
+	rest = strings.TrimLeft(rest, " \t\r\n")
+
+	if !strings.HasPrefix(rest, "\"") {
+		return result, eris.New("XSTR text has to be enclosed in quotes")
+	}
+
+	end := strings.IndexByte(rest[1:], '"')
+	if end == -1 {
+		return result, eris.New("Missing closing quote for XSTR text")
+	}
+
+	result.Text = rest[1 : end+1]
+	rest = strings.TrimLeft(rest[end+2:], " \t\r\n")
+	if !strings.HasPrefix(rest, ",") {
+		return result, eris.New("Missing ',' between XSTR text and ID")
+	}
+
+	rest = strings.TrimSpace(rest[1:])
+	end = strings.IndexByte(rest, ')')
+	if end == -1 {
+		return result, eris.New("Missing ')' after XSTR ID")
+	}
+
+	idText := strings.TrimSpace(rest[:end])
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		return result, eris.Errorf("XSTR ID %s is not an integer", idText)
+	}
+
+	if id < -1 {
+		return result, eris.Errorf("XSTR ID %d is invalid, use -1 for strings without translation", id)
+	}
+
+	if trailing := strings.TrimSpace(rest[end+1:]); trailing != "" {
+		return result, eris.Errorf("Unexpected text after XSTR: %s", trailing)
+	}
+
+	result.ID = id
+	return result, nil
+}
+
+// xstrOrString parses text as an XSTR if it looks like one or returns it unchanged otherwise.
+// Parse errors are reported at textRange.
+func xstrOrString(lex *Lexer, text string, textRange [4]int) interface{} {
+	if !isXSTR(text) {
+		return text
+	}
+
+	value, err := parseXSTR(text)
+	if err != nil {
+		lex.Report(eris.Wrap(NewParserError(err.Error(), textRange), ""))
+		return text
+	}
+
+	return value
+}
+
+func formatXSTR(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case XSTR:
+		return value.String(), nil
+	case string:
+		return value, nil
+	default:
+		return "", eris.Errorf("Expected an XSTR or string but got %T", value)
+	}
+}
+
+// XSTRValue is a single line string which may be translatable. The result is an XSTR or a
+// string if the value doesn't use XSTR().
+var XSTRValue = newGenericValueType("XSTR string", func(lex *Lexer) (interface{}, error) {
+	lex.beginSpan()
+	// Force the lexer to read a line
+	err := lex.readLine()
+	if err != nil {
+		lex.endSpan()
+		return nil, err
+	}
+
+	token, err := consumeValue(lex)
+	textRange := lex.endSpan()
+	if err != nil {
+		return nil, err
+	}
+
+	return xstrOrString(lex, strings.Trim(token.Content, " \n\t"), textRange), nil
+}, formatXSTR)
+
+// MultilineXSTRValue is the $end_multi_text terminated variant of XSTRValue.
+var MultilineXSTRValue = newGenericValueType("multiline XSTR text", func(lex *Lexer) (interface{}, error) {
+	lex.beginSpan()
+	result, err := lex.ReadMultilineText("$end_multi_text")
+	textRange := lex.endSpan()
+	if err != nil {
+		return nil, err
+	}
+
+	return xstrOrString(lex, strings.Trim(result, " \n\t"), textRange), nil
+}, func(value interface{}) (string, error) {
+	str, err := formatXSTR(value)
+	if err != nil {
+		return "", err
+	}
+
+	return str + "\n$end_multi_text", nil
+})
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestXSTRValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+		errors   int
+	}{
+		{`$Alt Name: XSTR("Ulysses, the fast one", 3024) ; comment`, XSTR{Text: "Ulysses, the fast one", ID: 3024}, 0},
+		{`$Alt Name: XSTR("Ulysses; the /* fast */ one", 3024) ; comment`, XSTR{Text: "Ulysses; the /* fast */ one", ID: 3024}, 0},
+		{`$Alt Name: XSTR( "Hornet" , -1 )`, XSTR{Text: "Hornet", ID: -1}, 0},
+		{`$Alt Name: GTF Ulysses`, "GTF Ulysses", 0},
+		{`$Alt Name: XSTR("Ulysses", abc)`, `XSTR("Ulysses", abc)`, 1},
+		{`$Alt Name: XSTR("Ulysses", -5)`, `XSTR("Ulysses", -5)`, 1},
+	}
+
+	item := ContainerItem{Name: "$Alt Name", Value: XSTRValue}
+	for _, test := range tests {
+		lexer := NewLexer(context.Background(), strings.NewReader(test.input+"\n#End\n"))
+		node, err := item.ParseOne(lexer, true)
+		if err != nil {
+			t.Fatalf("%s: %v", test.input, err)
+		}
+
+		if node.Value != test.expected {
+			t.Errorf("%s: expected %#v but got %#v", test.input, test.expected, node.Value)
+		}
+
+		if errors := lexer.Errors(); len(errors) != test.errors {
+			t.Errorf("%s: expected %d errors but got %v", test.input, test.errors, errors)
+		}
+	}
+}
+
+func TestMultilineXSTRValue(t *testing.T) {
+	item := ContainerItem{Name: "+Description", Value: MultilineXSTRValue}
+	lexer := NewLexer(context.Background(), strings.NewReader(`+Description: XSTR("First line
+second; line /* with comment markers */", 3025)
+$end_multi_text
+#End
+`))
+	node, err := item.ParseOne(lexer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := XSTR{Text: "First line\nsecond; line /* with comment markers */", ID: 3025}
+	if node.Value != expected {
+		t.Errorf("expected %#v but got %#v", expected, node.Value)
+	}
+
+	formatted, err := MultilineXSTRValue.Format(node.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if formatted != expected.String()+"\n$end_multi_text" {
+		t.Errorf("unexpected output %q", formatted)
+	}
+}
@@ -5,12 +5,22 @@ import "github.com/ngld/fso-table-parser/pkg/parser"
 func NewArmorTable() []parser.ContainerItem {
 	return []parser.ContainerItem{
 		Section("#Armor Type",
-			Required(StringValue("$Name")),
-			Multi(Section("$Damage Type",
+			Multi(Document(Section("$Name",
 				Required(StringValue("")),
-				StringValue("+Calculation"),
-				FloatValue("+Value"),
-			)),
+				Multi(Document(Section("$Damage Type",
+					Required(StringValue("")),
+					Document(StringValue("+Calculation"), parser.Documentation{
+						Description: "How +Value is applied to the damage, e.g. additive, multiplicative or exponential.",
+					}),
+					Document(FloatValue("+Value"), parser.Documentation{
+						Description: "Operand of the calculation.",
+					}),
+				), parser.Documentation{
+					Description: "Damage type whose damage this armor modifies. Weapons pick it with $Damage Type.",
+				})),
+			), parser.Documentation{
+				Description: "Name of the armor type. Ships and weapons reference it with $Armor Type.",
+			})),
 		),
 	}
 }
@@ -47,15 +47,34 @@ func StringListValue(name string) parser.ContainerItem {
 }
 
 func StringFlagsValue(name string, flags ...string) parser.ContainerItem {
-	// TODO: Validate flags
 	return parser.ContainerItem{
 		Name: name,
 		Value: parser.ValueList{
-			ValueParser: parser.StringFlag,
+			ValueParser: parser.Enum{
+				ValueParser: parser.StringFlag,
+				Allowed:     flags,
+			},
 		},
 	}
 }
 
+// XSTRValue is a translatable string like XSTR("Hornet", 3024). Plain strings are accepted as
+// well.
+func XSTRValue(name string) parser.ContainerItem {
+	return parser.ContainerItem{
+		Name:  name,
+		Value: parser.XSTRValue,
+	}
+}
+
+// MultilineXSTRValue is a translatable text terminated by $end_multi_text.
+func MultilineXSTRValue(name string) parser.ContainerItem {
+	return parser.ContainerItem{
+		Name:  name,
+		Value: parser.MultilineXSTRValue,
+	}
+}
+
 func MultilineStringValue(name string) parser.ContainerItem {
 	return parser.ContainerItem{
 		Name:  name,
@@ -77,11 +96,26 @@ func FloatValue(name string) parser.ContainerItem {
 	}
 }
 
+// FloatListValue is a list of exactly count floats in parentheses.
 func FloatListValue(name string, count int) parser.ContainerItem {
 	return parser.ContainerItem{
 		Name: name,
 		Value: parser.ValueList{
 			ValueParser: parser.FloatValue,
+			MinSize:     count,
+			MaxSize:     count,
+		},
+	}
+}
+
+// FixedFloatListValue is a list of exactly count floats without parentheses. The values can be
+// separated by commas.
+func FixedFloatListValue(name string, count int) parser.ContainerItem {
+	return parser.ContainerItem{
+		Name: name,
+		Value: parser.FixedList{
+			ValueParser: parser.FloatValue,
+			Size:        count,
 		},
 	}
 }
@@ -100,11 +134,20 @@ func ColorValue(name string) parser.ContainerItem {
 	}
 }
 
+// IntegerListValue is a list of exactly count integers in parentheses.
 func IntegerListValue(name string, count int) parser.ContainerItem {
+	return LimitedIntegerListValue(name, count, count)
+}
+
+// LimitedIntegerListValue is a list of min to max integers in parentheses. A limit of zero
+// disables that check.
+func LimitedIntegerListValue(name string, min, max int) parser.ContainerItem {
 	return parser.ContainerItem{
 		Name: name,
 		Value: parser.ValueList{
 			ValueParser: parser.IntegerValue,
+			MinSize:     min,
+			MaxSize:     max,
 		},
 	}
 }
@@ -133,11 +176,11 @@ func BooleanListValue(name string) parser.ContainerItem {
 }
 
 func EnumValue(name string, values ...string) parser.ContainerItem {
-	// TODO: validate
 	return parser.ContainerItem{
 		Name: name,
-		Value: parser.ValueList{
+		Value: parser.Enum{
 			ValueParser: parser.StringValue,
+			Allowed:     values,
 		},
 	}
 }
@@ -174,13 +217,28 @@ func Multi(item parser.ContainerItem) parser.ContainerItem {
 	return item
 }
 
+// Constrain adds value constraints like parser.Positive() to item.
+func Constrain(item parser.ContainerItem, constraints ...parser.Constraint) parser.ContainerItem {
+	item.Constraints = append(append([]parser.Constraint(nil), item.Constraints...), constraints...)
+	return item
+}
+
 func Deprecated(item parser.ContainerItem, msg string) parser.ContainerItem {
 	item.DeprecatedMessage = msg
 	return item
 }
 
+// Document adds the documentation shown when hovering over item's label.
+func Document(item parser.ContainerItem, doc parser.Documentation) parser.ContainerItem {
+	item.Documentation = doc
+	return item
+}
+
 func Nocreate() parser.ContainerItem {
-	return BooleanFlag("+nocreate")
+	return Document(BooleanFlag("+nocreate"), parser.Documentation{
+		Description: "Only valid in modular tables. Modifies an existing entry and skips this one if there is " +
+			"no entry with this name instead of creating a new one.",
+	})
 }
 
 func Join(containers ...[]parser.ContainerItem) []parser.ContainerItem {
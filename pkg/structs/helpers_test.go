@@ -0,0 +1,41 @@
+package structs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestEnumValue(t *testing.T) {
+	item := EnumValue("$Selection Effect", "FS2", "FS1", "off")
+	lexer := parser.NewLexer(context.Background(), strings.NewReader("$Selection Effect: FS1\n#End\n"))
+	node, err := item.ParseOne(lexer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// EnumValue used to expect a list like ( "FS1" ) and returned []interface{}
+	if value, ok := node.Value.(string); !ok || value != "FS1" {
+		t.Errorf("expected the single value FS1 but got %#v", node.Value)
+	}
+}
+
+func TestFixedFloatListValue(t *testing.T) {
+	item := FixedFloatListValue("+Values", 2)
+	lexer := parser.NewLexer(context.Background(), strings.NewReader("+Values: 1, 2.5\n+Values: 1\n#End\n"))
+	node, err := item.ParseOne(lexer, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values, ok := node.Value.([]interface{}); !ok || len(values) != 2 || values[1] != 2.5 {
+		t.Errorf("unexpected value %#v", node.Value)
+	}
+
+	_, err = item.ParseOne(lexer, true)
+	if err == nil || !strings.Contains(err.Error(), "Expected 2 values but found 1") {
+		t.Errorf("expected an error for the short list but got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package structs
+
+import (
+	"path"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+// TableDefinition describes one of FSO's table types.
+type TableDefinition struct {
+	// Name is the file name of the base table (e.g. ships.tbl).
+	Name string
+	// ModularSuffix is the suffix used by modular tables including the extension (e.g. -shp.tbm).
+	ModularSuffix string
+	// Schema returns the grammar for this table. It's nil for tables without a schema; those are
+	// parsed in generic mode.
+	Schema func() []parser.ContainerItem
+	// Symbols describes which values define or reference names used by other tables.
+	Symbols []index.Rule
+}
+
+// Generic is returned by LookupTable for files that don't match any known table.
+var Generic = TableDefinition{}
+
+var tableDefinitions = []TableDefinition{
+	{Name: "ships.tbl", ModularSuffix: "-shp.tbm", Schema: NewShipsTable, Symbols: shipSymbols},
+	{Name: "weapons.tbl", ModularSuffix: "-wep.tbm", Schema: NewWeaponsTable, Symbols: weaponSymbols},
+	{Name: "armor.tbl", ModularSuffix: "-amr.tbm", Schema: NewArmorTable, Symbols: armorSymbols},
+	{Name: "ai_profiles.tbl", ModularSuffix: "-aip.tbm"},
+	{Name: "asteroid.tbl", ModularSuffix: "-ast.tbm"},
+	{Name: "colors.tbl", ModularSuffix: "-clr.tbm"},
+	{Name: "cutscenes.tbl", ModularSuffix: "-csn.tbm"},
+	{Name: "fireball.tbl", ModularSuffix: "-fbl.tbm"},
+	{Name: "hud_gauges.tbl", ModularSuffix: "-hdg.tbm"},
+	{Name: "iff_defs.tbl", ModularSuffix: "-iff.tbm"},
+	{Name: "lightning.tbl", ModularSuffix: "-ltng.tbm"},
+	{Name: "mainhall.tbl", ModularSuffix: "-hall.tbm"},
+	{Name: "medals.tbl", ModularSuffix: "-mdl.tbm"},
+	{Name: "messages.tbl", ModularSuffix: "-msg.tbm"},
+	{Name: "music.tbl", ModularSuffix: "-mus.tbm"},
+	{Name: "objecttypes.tbl", ModularSuffix: "-obt.tbm"},
+	{Name: "post_processing.tbl", ModularSuffix: "-post.tbm"},
+	{Name: "rank.tbl", ModularSuffix: "-rnk.tbm"},
+	{Name: "scripting.tbl", ModularSuffix: "-sct.tbm"},
+	{Name: "sexps.tbl", ModularSuffix: "-sexp.tbm"},
+	{Name: "sounds.tbl", ModularSuffix: "-snd.tbm"},
+	{Name: "species_defs.tbl", ModularSuffix: "-sdf.tbm", Symbols: speciesSymbols},
+	{Name: "stars.tbl", ModularSuffix: "-str.tbm"},
+	{Name: "strings.tbl", ModularSuffix: "-lcl.tbm"},
+	{Name: "tstrings.tbl", ModularSuffix: "-tlc.tbm"},
+	{Name: "weapon_expl.tbl", ModularSuffix: "-wxp.tbm"},
+}
+
+// Tables returns all known table definitions.
+func Tables() []TableDefinition {
+	result := make([]TableDefinition, len(tableDefinitions))
+	copy(result, tableDefinitions)
+	return result
+}
+
+// LookupTable returns the definition matching filename which can either be a base table
+// (ships.tbl) or a modular table (mod-shp.tbm). Directories in filename are ignored and case
+// doesn't matter. Returns Generic and false if the file doesn't belong to a known table.
+func LookupTable(filename string) (TableDefinition, bool) {
+	if idx := strings.LastIndexAny(filename, "/\\"); idx != -1 {
+		filename = filename[idx+1:]
+	}
+
+	filename = strings.ToLower(filename)
+	for _, def := range tableDefinitions {
+		if filename == def.Name || (def.ModularSuffix != "" && strings.HasSuffix(filename, def.ModularSuffix)) {
+			return def, true
+		}
+	}
+
+	return Generic, false
+}
+
+// LookupSchema returns the schema for filename or nil if the file has to be parsed in generic
+// mode.
+func LookupSchema(filename string) []parser.ContainerItem {
+	def, _ := LookupTable(filename)
+	if def.Schema == nil {
+		return nil
+	}
+
+	return def.Schema()
+}
+
+// LookupSymbols returns the rules describing the names defined and referenced by filename. Besides
+// tables, missions (.fs2) and campaigns (.fc2) reference ship classes and weapons.
+func LookupSymbols(filename string) []index.Rule {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".fs2":
+		return missionSymbols
+	case ".fc2":
+		return campaignSymbols
+	}
+
+	def, _ := LookupTable(filename)
+	return def.Symbols
+}
+
+// UsesSexps returns true for files whose SEXPs can refer to ship classes, weapons, ... by name.
+// The index doesn't cover SEXP arguments, so renames have to check these files separately.
+func UsesSexps(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	return ext == ".fs2" || ext == ".fc2"
+}
@@ -0,0 +1,36 @@
+package structs
+
+import "testing"
+
+func TestLookupTable(t *testing.T) {
+	tests := []struct {
+		filename string
+		name     string
+		found    bool
+	}{
+		{"ships.tbl", "ships.tbl", true},
+		{"data/tables/weapons.tbl", "weapons.tbl", true},
+		{"mymod-shp.tbm", "ships.tbl", true},
+		{`data\tables\mymod-wep.tbm`, "weapons.tbl", true},
+		{"Ships.TBL", "ships.tbl", true},
+		{"MyMod-SHP.tbm", "ships.tbl", true},
+		{"ships.tbm", "", false},
+		{"readme.txt", "", false},
+		{"unknown.tbl", "", false},
+	}
+
+	for _, test := range tests {
+		def, found := LookupTable(test.filename)
+		if found != test.found || def.Name != test.name {
+			t.Errorf("%s: expected %q, %v but got %q, %v", test.filename, test.name, test.found, def.Name, found)
+		}
+
+		if !found && (def.Schema != nil || def.Symbols != nil) {
+			t.Errorf("%s: expected the generic definition", test.filename)
+		}
+	}
+
+	if def, _ := LookupTable("mymod-shp.tbm"); def.Schema == nil {
+		t.Errorf("expected modular ship tables to use the ships schema")
+	}
+}
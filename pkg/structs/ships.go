@@ -74,21 +74,23 @@ func NewShipsTable() []parser.ContainerItem {
 				BooleanFlag("+remove"),
 				StringValue("+Use Template"),
 				Either(
-					StringValue("$Alt Name"),
-					StringValue("$Display Name"),
+					XSTRValue("$Alt Name"),
+					XSTRValue("$Display Name"),
 				),
 				StringValue("$Short name"),
-				StringValue("$Species"),
-				StringValue("+Type"),
-				StringValue("+Maneuverability"),
-				StringValue("+Armor"),
-				StringValue("+Manufacturer"),
-				MultilineStringValue("+Description"),
-				StringValue("+Tech Title"),
-				MultilineStringValue("+Tech Description"),
-				StringValue("+Length"),
-				StringValue("+Gun Mounts"),
-				StringValue("+Missile Banks"),
+				Document(StringValue("$Species"), parser.Documentation{
+					Description: "Species of the ship. Must be defined in species_defs.tbl.",
+				}),
+				XSTRValue("+Type"),
+				XSTRValue("+Maneuverability"),
+				XSTRValue("+Armor"),
+				XSTRValue("+Manufacturer"),
+				MultilineXSTRValue("+Description"),
+				XSTRValue("+Tech Title"),
+				MultilineXSTRValue("+Tech Description"),
+				XSTRValue("+Length"),
+				XSTRValue("+Gun Mounts"),
+				XSTRValue("+Missile Banks"),
 				EnumValue("$Selection Effect", "FS2", "FS1", "off"),
 				StringValue("$Cockpit POF file"),
 				Vec3dValue("+Cockpit offset:"),
@@ -100,7 +102,9 @@ func NewShipsTable() []parser.ContainerItem {
 					StringValue("+Foreground"),
 					Required(StringValue("+Display Name")),
 				)),
-				StringValue("$POF file"),
+				Document(StringValue("$POF file"), parser.Documentation{
+					Description: "Model (.pof) used for the ship.",
+				}),
 				StringValue("$POF file Techroom"),
 				Section("$Texture Replace",
 					Multi(Section("+old",
@@ -110,7 +114,9 @@ func NewShipsTable() []parser.ContainerItem {
 				),
 				StringValue("$POF target file"),
 				IntegerValue("$POF target LOD"),
-				IntegerListValue("$Detail distance", 4),
+				Document(Constrain(IntegerListValue("$Detail distance", 4), parser.NonNegative(), parser.Ascending()), parser.Documentation{
+					Description: "Distance from the camera at which each of the model's detail levels is used.",
+				}),
 				Vec3dValue("$ND"),
 				IntegerValue("$Collision LOD"),
 				BooleanValue("$Enable Team Colors"),
@@ -159,7 +165,7 @@ func NewShipsTable() []parser.ContainerItem {
 					FloatValue("+Min Hitpoints"),
 					FloatValue("+Max Hitpoints"),
 					FloatValue("+Damage Multiplier"),
-					FloatValue("+Lightning Arc Percent:"),
+					Constrain(FloatValue("+Lightning Arc Percent"), parser.Percent()),
 					StringValue("+Ambient Sound"),
 					StringValue("+Collision Sound Light"),
 					StringValue("+Collision Sound Heavy"),
@@ -167,18 +173,46 @@ func NewShipsTable() []parser.ContainerItem {
 					StringValue("+Generic Debris POF file"),
 					IntegerValue("+Generic Debris Spew Num"),
 				),
-				FloatValue("$Density"),
-				FloatValue("$Damp"),
-				FloatValue("$Rotdamp"),
-				FloatValue("$Banking Constant"),
-				Vec3dValue("$Max Velocity"),
+				Document(Constrain(FloatValue("$Density"), parser.Positive()), parser.Documentation{
+					Description: "Density of the model. The mass is its volume multiplied by the density. Heavier ships are " +
+						"pushed around less by collisions and weapon impacts.",
+					Default: "1",
+				}),
+				Document(FloatValue("$Damp"), parser.Documentation{
+					Description: "Damping of linear movement. Higher values make the ship take longer to change its " +
+						"velocity; 0 changes it instantly.",
+					Default: "0",
+				}),
+				Document(FloatValue("$Rotdamp"), parser.Documentation{
+					Description: "Rotational damping: roughly the time in seconds the ship needs to reach its full rotation " +
+						"speed or to stop rotating. Higher values make the ship feel sluggish when turning.",
+					Default: "0",
+				}),
+				Document(FloatValue("$Banking Constant"), parser.Documentation{
+					Description: "How much the ship rolls into turns.",
+				}),
+				Document(Vec3dValue("$Max Velocity"), parser.Documentation{
+					Description: "Maximum velocity in m/s along the ship's x (sideways), y (vertical) and z (forward) axes.",
+				}),
 				Vec3dValue("$Player Minimum Velocity"),
-				Vec3dValue("$Rotation Time"),
-				FloatValue("$Rear Velocity"),
-				FloatValue("$Forward accel"),
-				FloatValue("$Forward decel"),
-				FloatValue("$Slide accel"),
-				FloatValue("$Slide decel"),
+				Document(Vec3dValue("$Rotation Time"), parser.Documentation{
+					Description: "Seconds needed for a full rotation around the x (pitch), y (yaw) and z (roll) axes.",
+				}),
+				Document(FloatValue("$Rear Velocity"), parser.Documentation{
+					Description: "Maximum velocity in m/s when flying backwards.",
+				}),
+				Document(FloatValue("$Forward accel"), parser.Documentation{
+					Description: "Seconds needed to accelerate from a standstill to the maximum forward velocity.",
+				}),
+				Document(FloatValue("$Forward decel"), parser.Documentation{
+					Description: "Seconds needed to slow down from the maximum forward velocity to a standstill.",
+				}),
+				Document(FloatValue("$Slide accel"), parser.Documentation{
+					Description: "Seconds needed to reach the maximum sideways or vertical velocity.",
+				}),
+				Document(FloatValue("$Slide decel"), parser.Documentation{
+					Description: "Seconds needed to stop sliding sideways or vertically.",
+				}),
 				BooleanSection("$Glide",
 					BooleanValue("+Dynamic Glide Cap"),
 					FloatValue("+Max Glide Speed"),
@@ -205,10 +239,18 @@ func NewShipsTable() []parser.ContainerItem {
 				NewWarpEffect("$Warpin"),
 				NewWarpEffect("$Warpout"),
 				[]parser.ContainerChild{
-					FloatValue("$Expl inner rad"),
-					FloatValue("$Expl outer rad"),
-					FloatValue("$Expl damage"),
-					FloatValue("$Expl blast"),
+					Document(FloatValue("$Expl inner rad"), parser.Documentation{
+						Description: "Radius in meters in which the ship's death explosion deals its full damage.",
+					}),
+					Document(FloatValue("$Expl outer rad"), parser.Documentation{
+						Description: "Radius in meters at which the damage of the death explosion has dropped to 0.",
+					}),
+					Document(FloatValue("$Expl damage"), parser.Documentation{
+						Description: "Damage dealt by the death explosion inside the inner radius.",
+					}),
+					Document(FloatValue("$Expl blast"), parser.Documentation{
+						Description: "Force with which the death explosion pushes nearby objects away.",
+					}),
 					BooleanValue("$Expl Propagates"),
 					BooleanValue("$Expl Splits Ship"),
 					FloatValue("$Propagating Expl Radius Multiplier"),
@@ -226,24 +268,28 @@ func NewShipsTable() []parser.ContainerItem {
 					StringValue("$Ship Death Effect"),
 					NewShipParticleEffect("$Ship Death Particles"),
 					NewShipParticleEffect("$Alternate Death Particles"),
-					FloatValue("$Vaporize Percent Chance"),
+					Document(Constrain(FloatValue("$Vaporize Percent Chance"), parser.Percent()), parser.Documentation{
+						Description: "Chance that the ship is vaporized instantly instead of going through its death roll.",
+						Default:     "0",
+						Since:       parser.Version{3, 6, 10},
+					}),
 					StringValue("$Shockwave Damage Type"),
 					FloatValue("$Shockwave Speed"),
 					FloatValue("$Shockwave Count"),
 					StringValue("$Shockwave model"),
 					StringValue("$Shockwave name"),
-					IntegerListValue("$Explosion Animations", maxFireballTypes),
+					LimitedIntegerListValue("$Explosion Animations", 0, maxFireballTypes),
 					FloatValue("$Weapon Model Draw Distance"),
 					// TODO: Proper allowed weapon parsing
 					WeaponBanksValue("$Allowed PBanks"),
 					WeaponBanksValue("$Allowed Dogfight PBanks"),
 					StringListValue("$Default PBanks"),
-					IntegerListValue("$PBank Capacity", 3),
+					LimitedIntegerListValue("$PBank Capacity", 0, 3),
 					BooleanListValue("$Show Primary Models"),
 					WeaponBanksValue("$Allowed SBanks"),
 					WeaponBanksValue("$Allowed Dogfight SBanks"),
 					StringListValue("$Default SBanks"),
-					IntegerListValue("$SBank Capacity", 4),
+					LimitedIntegerListValue("$SBank Capacity", 0, 4),
 					BooleanListValue("$Show Secondary Models"),
 					FloatValue("$Ship Recoil Modifier"),
 					Section("$Shields",
@@ -257,7 +303,10 @@ func NewShipsTable() []parser.ContainerItem {
 					ColorValue("$Shield Color"),
 					StringValue("$Shield Impact Explosion"),
 					FloatValue("$Max Shield Recharge"),
-					FloatValue("$Power Output"),
+					Document(FloatValue("$Power Output"), parser.Documentation{
+						Description: "Energy generated per second. It recharges the shields, the weapon energy and the " +
+							"afterburner fuel.",
+					}),
 					FloatValue("$Shield Regeneration Rate"),
 					FloatValue("$Support Shield Repair Rate"),
 					FloatValue("$Weapon Regeneration Rate"),
@@ -275,26 +324,48 @@ func NewShipsTable() []parser.ContainerItem {
 						FloatValue("$Max Weapon Energy"),
 						FloatValue("$Max Weapon Eng"),
 					),
-					FloatValue("$Hitpoints"),
+					Document(FloatValue("$Hitpoints"), parser.Documentation{
+						Description: "Hull strength of the ship.",
+						Default:     "100",
+					}),
 					FloatValue("$Hull Repair Rate"),
 					FloatValue("$Support Hull Repair Rate"),
 					FloatValue("$Subsystem Repair Rate"),
 					FloatValue("$Support Subsystem Repair Rate"),
-					StringValue("$Armor Type"),
-					StringValue("$Shield Armor Type"),
+					Document(StringValue("$Armor Type"), parser.Documentation{
+						Description: "Armor type from armor.tbl which modifies the damage dealt to the hull.",
+						Since:       parser.Version{3, 6, 10},
+					}),
+					Document(StringValue("$Shield Armor Type"), parser.Documentation{
+						Description: "Armor type from armor.tbl which modifies the damage dealt to the shields.",
+						Since:       parser.Version{3, 6, 10},
+					}),
 					Section("$Flags",
 						Required(StringListValue("")),
 						BooleanFlag("+noreplace"),
 					),
-					StringValue("$AI Class"),
+					Document(StringValue("$AI Class"), parser.Documentation{
+						Description: "Default AI class from ai.tbl. Missions can override it.",
+					}),
 					BooleanSection("$Afterburner",
-						Vec3dValue("+Aburn Max Vel"),
-						FloatValue("+Aburn For accel"),
+						Document(Vec3dValue("+Aburn Max Vel"), parser.Documentation{
+							Description: "Maximum velocity in m/s along the x, y and z axes while the afterburner is engaged.",
+						}),
+						Document(FloatValue("+Aburn For accel"), parser.Documentation{
+							Description: "Seconds needed to reach the afterburner's forward velocity.",
+						}),
 						FloatValue("+Aburn Max Reverse Vel"),
 						FloatValue("+Aburn Rev accel"),
-						FloatValue("+Aburn Fuel"),
-						FloatValue("+Aburn Burn Rate"),
-						FloatValue("+Aburn Rec Rate"),
+						Document(FloatValue("+Aburn Fuel"), parser.Documentation{
+							Description: "Amount of afterburner fuel.",
+						}),
+						Document(FloatValue("+Aburn Burn Rate"), parser.Documentation{
+							Description: "Afterburner fuel consumed per second while the afterburner is engaged.",
+						}),
+						Document(FloatValue("+Aburn Rec Rate"), parser.Documentation{
+							Description: "Afterburner fuel recharged per second while the afterburner isn't engaged. The energy is " +
+								"taken from the power output.",
+						}),
 						FloatValue("+Aburn Minimum Start Fuel"),
 						FloatValue("+Aburn Minimum Fuel to Burn"),
 						FloatValue("+Aburn Cooldown Time"),
@@ -305,18 +376,27 @@ func NewShipsTable() []parser.ContainerItem {
 						FloatValue("+Width"),
 						FloatValue("+Alpha"),
 						FloatValue("+Alpha End"),
-						FloatValue("+Alpha Decay Exponent"),
+						Constrain(FloatValue("+Alpha Decay Exponent"), parser.NonNegative()),
 						FloatValue("+Life"),
 						FloatValue("+Spread"),
 						IntegerValue("+Faded out Sections"),
 					),
 					StringValue("$Countermeasure type"),
-					IntegerValue("$Countermeasures"),
-					IntegerValue("$Scan time"),
+					Document(IntegerValue("$Countermeasures"), parser.Documentation{
+						Description: "Number of countermeasures the ship carries.",
+					}),
+					Document(IntegerValue("$Scan time"), parser.Documentation{
+						Description: "Milliseconds needed to scan the ship.",
+						Default:     "2000",
+					}),
 					FloatValue("$Scan range Normal"),
 					FloatValue("$Scan range Capital"),
-					FloatValue("$Ask Help Shield Percent"),
-					FloatValue("$Ask Help Hull Percent"),
+					Document(Constrain(FloatValue("$Ask Help Shield Percent"), parser.Fraction()), parser.Documentation{
+						Description: "Shield strength below which a friendly ship calls for help.",
+					}),
+					Document(Constrain(FloatValue("$Ask Help Hull Percent"), parser.Fraction()), parser.Documentation{
+						Description: "Hull strength below which a friendly ship calls for help.",
+					}),
 					StringValue("$EngineSnd"),
 					FloatValue("$Minimum Engine Volume"),
 					StringValue("$GlideStartSnd"),
@@ -422,7 +502,7 @@ func NewShipsTable() []parser.ContainerItem {
 						Required(FloatValue("+End Width")),
 						Required(FloatValue("+Start Alpha")),
 						Required(FloatValue("+End Alpha")),
-						FloatValue("+Alpha Decay Exponent"),
+						Constrain(FloatValue("+Alpha Decay Exponent"), parser.NonNegative()),
 						Required(FloatValue("+Max Life")),
 						FloatValue("+Spread"),
 						Required(IntegerValue("+Spew Time")),
@@ -446,8 +526,8 @@ func NewShipsTable() []parser.ContainerItem {
 							"Forward",
 							"Reverse",
 						),
-						FloatListValue("+Position", 3),
-						FloatListValue("+Normal", 3),
+						Vec3dValue("+Position"),
+						Vec3dValue("+Normal"),
 						StringValue("+Texture"),
 						FloatValue("+Radius"),
 						FloatValue("+Length"),
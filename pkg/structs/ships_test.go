@@ -0,0 +1,33 @@
+package structs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestShipConstraints(t *testing.T) {
+	parse := func(percent string) *parser.Lexer {
+		table := "#Ship Classes\n$Name: GTF Ulysses\n$Debris:\n+Lightning Arc Percent: " + percent + "\n#End\n"
+		lexer := parser.NewLexer(context.Background(), strings.NewReader(table))
+		if _, err := parser.ParseTable(lexer, NewShipsTable()); err != nil {
+			t.Fatalf("failed to parse: %+v", err)
+		}
+
+		if errs := lexer.Errors(); len(errs) > 0 {
+			t.Errorf("unexpected errors: %v", errs)
+		}
+		return lexer
+	}
+
+	warnings := parse("250").Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "Value 250 must be between 0 and 100") {
+		t.Errorf("expected a warning for the percentage but got %v", warnings)
+	}
+
+	if warnings := parse("50").Warnings(); len(warnings) > 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
@@ -0,0 +1,121 @@
+package structs
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func definition(kind index.Kind, path ...string) index.Rule {
+	return index.Rule{Path: path, Kind: kind, Definition: true}
+}
+
+func reference(kind index.Kind, path ...string) index.Rule {
+	return index.Rule{Path: path, Kind: kind}
+}
+
+// +Armor is skipped on purpose: it's the armor description shown in the tech room, not an armor
+// type.
+var shipSymbols = []index.Rule{
+	definition(index.ShipClass, "#Ship Classes", "$Name"),
+	definition(index.EngineWash, "#Engine Wash Info", "$Name"),
+	reference(index.ShipClass, "#Default Player Ship", "$Name"),
+	reference(index.ShipClass, "#Ship Classes", "$Name", "+Use Template"),
+	reference(index.Species, "#Ship Classes", "$Name", "$Species"),
+	reference(index.ArmorType, "$Armor Type"),
+	reference(index.ArmorType, "$Shield Armor Type"),
+	reference(index.Weapon, "$Allowed PBanks"),
+	reference(index.Weapon, "$Allowed Dogfight PBanks"),
+	reference(index.Weapon, "$Default PBanks"),
+	reference(index.Weapon, "$Allowed SBanks"),
+	reference(index.Weapon, "$Allowed Dogfight SBanks"),
+	reference(index.Weapon, "$Default SBanks"),
+	reference(index.EngineWash, "$Engine Wash"),
+	reference(index.DamageType, "$Debris", "+Damage Type"),
+	reference(index.DamageType, "$Shockwave Damage Type"),
+}
+
+var weaponSymbols = []index.Rule{
+	definition(index.Weapon, "#Primary Weapons", "$Name"),
+	definition(index.Weapon, "#Secondary Weapons", "$Name"),
+	definition(index.Weapon, "#Beam Weapons", "$Name"),
+	definition(index.Weapon, "#Countermeasures", "$Name"),
+	reference(index.Weapon, "$Name", "+Use Template"),
+	reference(index.Weapon, "#Player Weapon Precedence", "$Player Weapon Precedence"),
+	reference(index.ArmorType, "$Armor Type"),
+	reference(index.DamageType, "$Damage Type"),
+}
+
+// Damage types don't have a table of their own; every armor type lists the ones it handles.
+var armorSymbols = []index.Rule{
+	definition(index.ArmorType, "#Armor Type", "$Name"),
+	definition(index.DamageType, "#Armor Type", "$Name", "$Damage Type"),
+}
+
+var speciesSymbols = []index.Rule{
+	definition(index.Species, "#Species Defs", "$Species_Name"),
+}
+
+// Missions are parsed in generic mode where +Labels belong to the $Label preceding them.
+var missionSymbols = []index.Rule{
+	reference(index.ShipClass, "#Objects", "$Class"),
+	reference(index.ShipClass, "#Players", "$Ship Choices"),
+	reference(index.Weapon, "+Weaponry Pool"),
+	reference(index.Weapon, "+Primary Banks"),
+	reference(index.Weapon, "+Secondary Banks"),
+}
+
+// Campaigns list the ships and weapons available at the start of the campaign.
+var campaignSymbols = []index.Rule{
+	reference(index.ShipClass, "+Starting Ships"),
+	reference(index.Weapon, "+Starting Weapons"),
+}
+
+// InactiveSymbols returns the symbols on the lines of file which version disables with version
+// comments. Each of these lines is enabled either for the oldest or for the newest engine version,
+// so content is parsed with those to find them.
+func InactiveSymbols(ctx context.Context, file, content string, version parser.Version) ([]index.Symbol, error) {
+	rules := LookupSymbols(file)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	_, regions, _ := parser.ApplyVersionComments(content, version)
+	needed := make(map[parser.Version]bool)
+	for _, region := range regions {
+		if region.Active {
+			continue
+		}
+
+		if region.Negated {
+			needed[parser.Version{}] = true
+		} else {
+			needed[parser.LatestVersion] = true
+		}
+	}
+
+	result := make([]index.Symbol, 0)
+	for _, other := range []parser.Version{{}, parser.LatestVersion} {
+		if !needed[other] {
+			continue
+		}
+
+		var nodes []*parser.Node
+		var err error
+		opt := parser.WithEngineVersion(other)
+		if schema := LookupSchema(file); schema == nil {
+			nodes, err = parser.ParseGeneric(ctx, content, opt)
+		} else {
+			nodes, err = parser.ParseTable(parser.NewLexer(ctx, strings.NewReader(content), opt), schema)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, index.InactiveSymbols(file, content, nodes, rules, regions)...)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package structs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/index"
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestDamageTypeSymbols(t *testing.T) {
+	tables := map[string]string{
+		"armor.tbl": `#Armor Type
+$Name: Light
+$Damage Type: Kinetic
+	+Calculation: multiplication
+	+Value: 1.5
+$Damage Type: Energy
+	+Calculation: multiplication
+	+Value: 0.5
+$Name: Heavy
+$Damage Type: Kinetic
+	+Calculation: multiplication
+	+Value: 0.8
+#End
+`,
+		"weapons.tbl": `#Primary Weapons
+$Name: Subach HL-7
+$Damage: 15
+$Damage Type: Energy
+$Name: Prometheus R
+$Damage: 20
+$Damage Type: Plasma
+$Armor Type: Light
+#End
+`,
+	}
+
+	idx := index.New()
+	for name, content := range tables {
+		lexer := parser.NewLexer(context.Background(), strings.NewReader(content))
+		nodes, err := parser.ParseTable(lexer, LookupSchema(name))
+		if err != nil {
+			t.Fatalf("%s: %+v", name, err)
+		}
+
+		idx.Add(name, content, nodes, LookupSymbols(name))
+	}
+
+	if defs := idx.Definitions(index.DamageType, "kinetic"); len(defs) != 2 || defs[0].File != "armor.tbl" {
+		t.Errorf("expected Kinetic to be defined by both armor types but got %+v", defs)
+	}
+
+	refs := idx.References(index.DamageType, "Energy")
+	if len(refs) != 1 || refs[0].File != "weapons.tbl" || refs[0].Range != [4]int{4, 14, 4, 20} {
+		t.Errorf("unexpected references %+v", refs)
+	}
+
+	unresolved := idx.Unresolved()
+	if len(unresolved) != 1 || unresolved[0].Kind != index.DamageType || unresolved[0].Name != "Plasma" {
+		t.Errorf("expected Plasma to be unresolved but got %+v", unresolved)
+	}
+}
@@ -0,0 +1,384 @@
+package structs
+
+import "github.com/ngld/fso-table-parser/pkg/parser"
+
+var weaponFlags = []string{
+	"Electronics",
+	"Spawn *",
+	"Remote Detonate",
+	"Puncture",
+	"Big Ship",
+	"Huge",
+	"Bomber+",
+	"Child",
+	"Bomb",
+	"No Dumbfire",
+	"In tech database",
+	"Player allowed",
+	"Particle Spew",
+	"EMP",
+	"Esuck",
+	"Flak",
+	"Corkscrew",
+	"Shudder",
+	"Lockarm",
+	"Beam",
+	"Stream",
+	"Supercap",
+	"Countermeasure",
+	"Ballistic",
+	"Pierce Shields",
+	"Local SSM",
+	"Tagged Only",
+	"Beam No Whack",
+	"Cycle",
+	"Small Only",
+	"Same Turret Cooldown",
+	"Apply No Light",
+	"Training",
+	"Smart Spawn",
+	"Inherit Parent Target",
+	"No EMP Kill",
+	"Variable Lead Homing",
+	"Untargeted Heat Seeker",
+	"No Radius Doubling",
+	"No Subsystem Homing",
+	"No Lifeleft Penalty",
+	"Can Be Targeted",
+	"Show On Radar",
+	"Show Friendly On Radar",
+	"Capital+",
+	"Chain External Model FPS",
+	"External Model Launcher",
+	"Takes Blast Damage",
+	"Takes Shockwave Damage",
+	"Hide From Radar",
+	"Render Flak",
+	"Ciws",
+	"Anti-Subsystem Beam",
+	"No Primary Linking",
+	"Same EMP Time for Capships",
+	"No Primary Linked Penalty",
+	"No Homing Speed Ramp",
+	"Pulls Aspect Seekers",
+	"Interceptable",
+	"Die On Lost Lock",
+	"No Impact Spew",
+	"Require Exact LOS",
+	"Can Damage Shooter",
+	"Heals",
+}
+
+func NewShockwave(prefix string) []parser.ContainerChild {
+	return []parser.ContainerChild{
+		FloatValue(prefix + "Inner Radius"),
+		FloatValue(prefix + "Outer Radius"),
+		FloatValue(prefix + "Shockwave Speed"),
+		Vec3dValue(prefix + "Shockwave Rotation"),
+		StringValue(prefix + "Shockwave Model"),
+		StringValue(prefix + "Shockwave name"),
+	}
+}
+
+func NewWeaponEntry() parser.ContainerItem {
+	return Multi(Section("$Name", JoinChildren([]parser.ContainerChild{
+		Required(StringValue("")),
+		Nocreate(),
+		BooleanFlag("+remove"),
+		StringValue("+Use Template"),
+		XSTRValue("$Alt name"),
+		XSTRValue("+Title"),
+		MultilineXSTRValue("+Description"),
+		XSTRValue("+Tech Title"),
+		StringValue("+Tech Anim"),
+		MultilineXSTRValue("+Tech Description"),
+		Section("$Tech Model",
+			Required(StringValue("")),
+			Vec3dValue("+Closeup_pos"),
+			FloatValue("+Closeup_zoom"),
+		),
+		StringValue("$Turret Name"),
+		Document(EnumValue("$Subtype", "Laser", "Missile", "Beam"), parser.Documentation{
+			Description: "Kind of weapon. Lasers are fired from primary banks, missiles from secondary banks.",
+		}),
+		Document(StringValue("$Model file"), parser.Documentation{
+			Description: "Model (.pof) used for the weapon. Lasers without a model are drawn with $Laser Bitmap instead.",
+		}),
+		StringValue("$POF target file"),
+		IntegerValue("$POF target LOD"),
+		Constrain(IntegerListValue("$Detail distance", 4), parser.NonNegative(), parser.Ascending()),
+		StringValue("$External Model File"),
+		FloatValue("$Submodel Rotation Speed"),
+		FloatValue("$Submodel Rotation Acceleration"),
+		StringValue("$Laser Bitmap"),
+		StringValue("$Laser Glow"),
+		ColorValue("$Laser Color"),
+		ColorValue("$Laser Color2"),
+		FloatValue("$Laser Length"),
+		FloatValue("$Laser Head-Radius"),
+		FloatValue("$Laser Tail-Radius"),
+		FloatValue("$Collision Radius Override"),
+		Document(FloatValue("$Mass"), parser.Documentation{
+			Description: "Mass of the weapon. Together with the velocity it determines how hard impacts push the target.",
+		}),
+		Document(FloatValue("$Velocity"), parser.Documentation{
+			Description: "Speed of the weapon in m/s.",
+		}),
+		Document(FloatValue("$Fire Wait"), parser.Documentation{
+			Description: "Seconds between two shots of the same bank.",
+		}),
+		Document(FloatValue("$Damage"), parser.Documentation{
+			Description: "Damage dealt by a single hit before the armor, shield and subsystem factors are applied.",
+		}),
+		Document(StringValue("$Damage Type"), parser.Documentation{
+			Description: "Damage type from armor.tbl which decides how the target's armor modifies the damage.",
+			Since:       parser.Version{3, 6, 10},
+		}),
+		FloatValue("$Arm time"),
+		FloatValue("$Arm distance"),
+		FloatValue("$Arm radius"),
+		FloatValue("$Detonation Range"),
+		FloatValue("$Detonation Radius"),
+		FloatValue("$Flak Detonation Accuracy"),
+		FloatValue("$Flak Targeting Accuracy"),
+		FloatValue("$Untargeted Flak Range Penalty"),
+		FloatValue("$Blast Force"),
+	},
+		NewShockwave("$"),
+		[]parser.ContainerChild{
+			BooleanSection("$Dinky shockwave", JoinChildren(
+				NewShockwave("+"),
+				[]parser.ContainerChild{
+					FloatValue("+Damage"),
+					FloatValue("+Blast Force"),
+				},
+			)...),
+			Document(FloatValue("$Armor Factor"), parser.Documentation{
+				Description: "Multiplier applied to the damage dealt to the hull.",
+				Default:     "1",
+			}),
+			Document(FloatValue("$Shield Factor"), parser.Documentation{
+				Description: "Multiplier applied to the damage dealt to the shields.",
+				Default:     "1",
+			}),
+			Document(FloatValue("$Subsystem Factor"), parser.Documentation{
+				Description: "Multiplier applied to the damage dealt to subsystems.",
+				Default:     "1",
+			}),
+			FloatValue("$Lifetime Min"),
+			FloatValue("$Lifetime Max"),
+			Document(FloatValue("$Lifetime"), parser.Documentation{
+				Description: "Seconds the weapon flies before it disappears. The range is roughly the velocity " +
+					"multiplied by the lifetime.",
+			}),
+			Document(FloatValue("$Energy Consumed"), parser.Documentation{
+				Description: "Weapon energy used per shot by primaries. Secondaries ignore it.",
+			}),
+			FloatValue("$Cargo Size"),
+			Document(BooleanSection("$Homing",
+				EnumValue("+Type", "HEAT", "ASPECT", "JAVELIN"),
+				FloatValue("+Turn Time"),
+				FloatValue("+View Cone"),
+				FloatValue("+Min Lock Time"),
+				IntegerValue("+Lock Pixels/Sec"),
+				IntegerValue("+Catch-up Pixels/Sec"),
+				IntegerValue("+Catch-up Penalty"),
+				IntegerValue("+Seeker Strength"),
+				FloatValue("+Target Lead Scaler"),
+				IntegerValue("+Target Lock Restriction"),
+				BooleanValue("+Independent Seekers"),
+				StringListValue("+Ship Types"),
+				StringListValue("+Ship Classes"),
+				StringListValue("+Species"),
+				StringListValue("+IFF"),
+			), parser.Documentation{
+				Description: "Whether the weapon homes in on its target. +Type picks how it acquires the target.",
+				Default:     "NO",
+			}),
+			IntegerValue("$Swarm"),
+			IntegerValue("$SwarmWait"),
+			FloatValue("$Free Flight Time"),
+			FloatValue("$Free Flight Speed"),
+			StringValue("$LaunchSnd"),
+			StringValue("$ImpactSnd"),
+			StringValue("$Disarmed ImpactSnd"),
+			StringValue("$FlyBySnd"),
+			StringValue("$TrackingSnd"),
+			StringValue("$LockedSnd"),
+			Section("$InFlightSnd",
+				Required(StringValue("")),
+				EnumValue("+Inflight sound type", "TARGETED", "UNTARGETED", "ALWAYS"),
+			),
+			StringValue("$Model"),
+			IntegerValue("$Rearm Rate"),
+			Document(FloatValue("$Weapon Range"), parser.Documentation{
+				Description: "Maximum distance at which the AI and turrets fire the weapon. Defaults to the range " +
+					"derived from the velocity and lifetime.",
+			}),
+			FloatValue("$Weapon Min Range"),
+			BooleanValue("$Pierce Objects"),
+			StringFlagsValue("$Flags", weaponFlags...),
+			Multi(FloatValue("$Spawn Angle")),
+			Multi(FloatValue("$Spawn Minimum Angle")),
+			Multi(FloatValue("$Spawn Interval")),
+			Section("$Trail",
+				FloatValue("+Start Width"),
+				FloatValue("+End Width"),
+				FloatValue("+Start Alpha"),
+				FloatValue("+End Alpha"),
+				FloatValue("+Max Life"),
+				Required(StringValue("+Bitmap")),
+				IntegerValue("+Faded Out Sections"),
+			),
+			StringValue("$Icon"),
+			StringValue("$Anim"),
+			StringValue("$Impact Explosion"),
+			FloatValue("$Impact Explosion Radius"),
+			FloatValue("$Shield Impact Explosion Radius"),
+			StringValue("$Dinky Impact Explosion"),
+			FloatValue("$Dinky Impact Explosion Radius"),
+			StringValue("$Piercing Impact Explosion"),
+			FloatValue("$Piercing Impact Radius"),
+			FloatValue("$Piercing Impact Velocity"),
+			FloatValue("$Piercing Impact Splash Velocity"),
+			FloatValue("$Piercing Impact Variance"),
+			FloatValue("$Piercing Impact Life"),
+			IntegerValue("$Piercing Impact Particles"),
+			StringValue("$Muzzleflash"),
+			FloatValue("$EMP Intensity"),
+			FloatValue("$EMP Time"),
+			FloatValue("$Leech Weapon"),
+			FloatValue("$Leech Afterburner"),
+			Section("$Corkscrew",
+				IntegerValue("+Num Fired"),
+				FloatValue("+Radius"),
+				IntegerValue("+Fire Delay"),
+				BooleanValue("+Counter rotate"),
+				FloatValue("+Twist"),
+			),
+			Section("$Electronics",
+				Either(
+					VoidValue("+New Style"),
+					VoidValue("+Old Style"),
+				),
+				FloatValue("+Area Of Effect"),
+				IntegerValue("+Intensity"),
+				IntegerValue("+Lifetime"),
+				FloatValue("+Engine Multiplier"),
+				FloatValue("+Weapon Multiplier"),
+				FloatValue("+Beam Turret Weapon Multiplier"),
+				FloatValue("+Sensors Multiplier"),
+				IntegerValue("+Randomness Time"),
+			),
+			Section("$Lssm",
+				FloatValue("+Warpout Delay"),
+				FloatValue("+Warpin Delay"),
+				FloatValue("+Stage 5 Velocity"),
+				FloatValue("+Warpin Radius"),
+				FloatValue("+Lock Range"),
+			),
+			// Countermeasures only
+			FloatValue("+Heat Effectiveness"),
+			FloatValue("+Aspect Effectiveness"),
+			FloatValue("+Effective Radius"),
+			FloatValue("+Missile Detonation Radius"),
+			BooleanValue("+Single Missile Kill"),
+			IntegerValue("$Pulse Interval"),
+			Section("$Beam Info",
+				StringValue("+Type"),
+				FloatValue("+Life"),
+				IntegerValue("+Warmup"),
+				IntegerValue("+Warmdown"),
+				FloatValue("+Radius"),
+				IntegerValue("+PCount"),
+				FloatValue("+PRadius"),
+				FloatValue("+PAngle"),
+				StringValue("+PAni"),
+				Multi(FloatValue("+Miss Factor")),
+				StringValue("+BeamSound"),
+				StringValue("+WarmupSound"),
+				StringValue("+WarmdownSound"),
+				StringValue("+Muzzleglow"),
+				IntegerValue("+Shots"),
+				FloatValue("+ShrinkFactor"),
+				FloatValue("+ShrinkPct"),
+				FloatValue("+Range"),
+				FloatValue("+Attenuation"),
+				FloatValue("+BeamWidth"),
+				Multi(Section("$Section",
+					IntegerValue("+Index"),
+					FloatValue("+Width"),
+					StringValue("+Texture"),
+					StringValue("+RGBA Inner"),
+					StringValue("+RGBA Outer"),
+					FloatValue("+Flicker"),
+					FloatValue("+Zadd"),
+					IntegerValue("+Tile Type"),
+					FloatValue("+Tile Factor"),
+					FloatValue("+Translation"),
+				)),
+			),
+			Section("$Pspew",
+				IntegerValue("+Count"),
+				IntegerValue("+Time"),
+				FloatValue("+Vel"),
+				FloatValue("+Radius"),
+				FloatValue("+Life"),
+				FloatValue("+Scale"),
+				StringValue("+Bitmap"),
+			),
+			StringValue("$Tag"),
+			StringValue("$SSM"),
+			Section("$FOF",
+				Required(FloatValue("")),
+				FloatValue("+FOF Reset Rate"),
+				FloatValue("+FOF Spread Rate"),
+			),
+			IntegerValue("$Shots"),
+			BooleanSection("$Transparent",
+				FloatValue("+Alpha"),
+				FloatValue("+Alpha Min"),
+				FloatValue("+Alpha Max"),
+				FloatValue("+Alpha Cycle"),
+				BooleanFlag("+No Light"),
+			),
+			FloatValue("$Weapon Hitpoints"),
+			Document(StringValue("$Armor Type"), parser.Documentation{
+				Description: "Armor type from armor.tbl which modifies the damage dealt to the weapon itself.",
+				Since:       parser.Version{3, 6, 10},
+			}),
+			IntegerValue("$Burst Shots"),
+			FloatValue("$Burst Delay"),
+			StringValue("$Thruster Flame Effect"),
+			StringValue("$Thruster Glow Effect"),
+			FloatValue("$Thruster Glow Radius Factor"),
+			Multi(Section("$Substitute",
+				Required(StringValue("")),
+				Either(
+					IntegerValue("+period"),
+					IntegerValue("+index"),
+					IntegerValue("+random range"),
+				),
+			)),
+		})...))
+}
+
+func NewWeaponsTable() []parser.ContainerItem {
+	return []parser.ContainerItem{
+		Section("#Primary Weapons",
+			NewWeaponEntry(),
+		),
+		Section("#Secondary Weapons",
+			NewWeaponEntry(),
+		),
+		Section("#Beam Weapons",
+			NewWeaponEntry(),
+		),
+		Section("#Countermeasures",
+			NewWeaponEntry(),
+		),
+		Section("#Player Weapon Precedence",
+			Required(StringListValue("$Player Weapon Precedence")),
+		),
+	}
+}
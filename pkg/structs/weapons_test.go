@@ -0,0 +1,100 @@
+package structs
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ngld/fso-table-parser/pkg/parser"
+)
+
+func TestWeaponsTable(t *testing.T) {
+	data, err := os.ReadFile("testdata/weapons.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lexer := parser.NewLexer(context.Background(), strings.NewReader(string(data)))
+	nodes, err := parser.ParseTable(lexer, NewWeaponsTable())
+	if err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+
+	if errs := lexer.Errors(); len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if warnings := lexer.Warnings(); len(warnings) > 0 {
+		t.Fatalf("unexpected warnings: %v", warnings)
+	}
+
+	weapons := make(map[string]*parser.Node)
+	for _, section := range nodes {
+		for _, entry := range section.ChildrenNamed("$Name") {
+			weapons[entry.InlineValue().(string)] = entry
+		}
+	}
+	if len(weapons) != 5 {
+		t.Fatalf("expected 5 weapons but found %d", len(weapons))
+	}
+
+	laser := weapons["Subach HL-7"]
+	if laser == nil {
+		t.Fatal("Subach HL-7 is missing")
+	}
+	if value := laser.Child("$Subtype").Value; value != "Laser" {
+		t.Errorf("unexpected subtype %v", value)
+	}
+	if color, ok := laser.Child("$Laser Color").Value.([]int); !ok || len(color) != 3 || color[0] != 250 {
+		t.Errorf("unexpected laser color %#v", laser.Child("$Laser Color").Value)
+	}
+	if flags := laser.Child("$Flags").Value.([]interface{}); len(flags) != 2 || flags[1] != "player allowed" {
+		t.Errorf("unexpected flags %#v", flags)
+	}
+
+	homing := map[string]string{"MX-50": "ASPECT", "Harpoon": "HEAT", "Hornet": "JAVELIN"}
+	for name, homingType := range homing {
+		entry := weapons[name]
+		if entry == nil {
+			t.Errorf("%s is missing", name)
+			continue
+		}
+
+		node := entry.Child("$Homing")
+		if node == nil || node.Child("+Type") == nil || node.Child("+Type").Value != homingType {
+			t.Errorf("%s: expected %s homing", name, homingType)
+		}
+	}
+
+	beam := weapons["LRed"]
+	if beam == nil {
+		t.Fatal("LRed is missing")
+	}
+	info := beam.Child("$Beam Info")
+	if info == nil {
+		t.Fatal("$Beam Info is missing")
+	}
+	if misses := info.ChildrenNamed("+Miss Factor"); len(misses) != 2 {
+		t.Errorf("expected 2 miss factors but found %d", len(misses))
+	}
+
+	sections := info.ChildrenNamed("$Section")
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 beam sections but found %d", len(sections))
+	}
+	if texture := sections[1].Child("+Texture"); texture == nil || texture.Value != "beamglow2" {
+		t.Errorf("unexpected texture of the second beam section")
+	}
+}
+
+func TestWeaponsTableWithoutOptionalSections(t *testing.T) {
+	lexer := parser.NewLexer(context.Background(), strings.NewReader("#Primary Weapons\n$Name: A\n$Subtype: Laser\n#End\n"))
+	_, err := parser.ParseTable(lexer, NewWeaponsTable())
+	if err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+
+	if errs := lexer.Errors(); len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
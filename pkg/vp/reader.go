@@ -0,0 +1,372 @@
+// Package vp reads and writes Volition Pack (.vp) archives.
+//
+// A VP file starts with a 16 byte header ("VPVP", version, directory offset, entry count)
+// followed by the file contents and the directory. Each directory entry is 44 bytes long: offset,
+// size, a 32 byte zero terminated name and a unix timestamp. Entries with a size of zero are
+// folders; a folder named ".." closes the current folder. All numbers are little endian int32s.
+package vp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+const (
+	headerSize = 16
+	entrySize  = 44
+	nameSize   = 32
+	version    = 2
+)
+
+var signature = [4]byte{'V', 'P', 'V', 'P'}
+
+type header struct {
+	Signature [4]byte
+	Version   int32
+	DirOffset int32
+	DirCount  int32
+}
+
+type dirEntry struct {
+	Offset    int32
+	Size      int32
+	Name      [nameSize]byte
+	Timestamp int32
+}
+
+type entry struct {
+	name     string
+	offset   int64
+	size     int64
+	modTime  time.Time
+	isDir    bool
+	children []*entry
+}
+
+// Archive is an opened VP file. It implements fs.FS; paths are matched case-insensitively like
+// the engine does.
+type Archive struct {
+	reader  io.ReaderAt
+	closer  io.Closer
+	root    *entry
+	entries map[string]*entry
+}
+
+var (
+	_ fs.FS        = (*Archive)(nil)
+	_ fs.ReadDirFS = (*Archive)(nil)
+)
+
+// OpenFile opens the VP file at path. The archive has to be closed after use.
+func OpenFile(path string) (*Archive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open %s", path)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, eris.Wrapf(err, "failed to read %s", path)
+	}
+
+	archive, err := NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, eris.Wrapf(err, "failed to read %s", path)
+	}
+
+	archive.closer = file
+	return archive, nil
+}
+
+// NewReader reads the directory of the VP archive in r which is size bytes long. The header is
+// checked against size before anything is allocated since it can't be trusted.
+func NewReader(r io.ReaderAt, size int64) (*Archive, error) {
+	var hdr header
+	err := binary.Read(io.NewSectionReader(r, 0, headerSize), binary.LittleEndian, &hdr)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read header")
+	}
+
+	if hdr.Signature != signature {
+		return nil, eris.New("not a VP file")
+	}
+
+	if hdr.Version != version {
+		return nil, eris.Errorf("unsupported VP version %d", hdr.Version)
+	}
+
+	if hdr.DirOffset < headerSize || hdr.DirCount < 0 {
+		return nil, eris.New("invalid VP header")
+	}
+
+	if int64(hdr.DirOffset)+int64(hdr.DirCount)*entrySize > size {
+		return nil, eris.Errorf("directory with %d entries doesn't fit into the archive (%d bytes)", hdr.DirCount, size)
+	}
+
+	raw := make([]dirEntry, hdr.DirCount)
+	err = binary.Read(io.NewSectionReader(r, int64(hdr.DirOffset), int64(hdr.DirCount)*entrySize), binary.LittleEndian, raw)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read directory")
+	}
+
+	archive := &Archive{
+		reader:  r,
+		root:    &entry{name: ".", isDir: true},
+		entries: make(map[string]*entry),
+	}
+	archive.entries["."] = archive.root
+
+	stack := []*entry{archive.root}
+	dirPath := ""
+	for _, item := range raw {
+		name := string(item.Name[:])
+		if idx := strings.IndexByte(name, 0); idx != -1 {
+			name = name[:idx]
+		}
+
+		if item.Size == 0 && name == ".." {
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+				dirPath = path.Dir(dirPath)
+				if dirPath == "." {
+					dirPath = ""
+				}
+			}
+			continue
+		}
+
+		if name == "" || strings.ContainsAny(name, "/\\") {
+			return nil, eris.Errorf("invalid entry name %q", name)
+		}
+
+		// Folders have a size of zero; their offset doesn't matter
+		if item.Size < 0 || (item.Size > 0 && (item.Offset < 0 || int64(item.Offset)+int64(item.Size) > size)) {
+			return nil, eris.Errorf("entry %q points outside of the archive", name)
+		}
+
+		node := &entry{
+			name:    name,
+			offset:  int64(item.Offset),
+			size:    int64(item.Size),
+			modTime: time.Unix(int64(item.Timestamp), 0),
+			isDir:   item.Size == 0,
+		}
+
+		fullPath := path.Join(dirPath, name)
+		key := strings.ToLower(fullPath)
+		parent := stack[len(stack)-1]
+		if existing, ok := archive.entries[key]; ok && existing.isDir && node.isDir {
+			// Some tools repeat folders; merge them.
+			node = existing
+		} else {
+			parent.children = append(parent.children, node)
+			archive.entries[key] = node
+		}
+
+		if node.isDir {
+			stack = append(stack, node)
+			dirPath = fullPath
+		}
+	}
+
+	return archive, nil
+}
+
+// Close closes the underlying file if the archive was opened with OpenFile.
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+
+	return a.closer.Close()
+}
+
+func (a *Archive) lookup(op, name string) (*entry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	node, ok := a.entries[strings.ToLower(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return node, nil
+}
+
+// Open opens the named file or folder.
+func (a *Archive) Open(name string) (fs.File, error) {
+	node, err := a.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.isDir {
+		return &dirFile{entry: node}, nil
+	}
+
+	return &file{
+		entry:         node,
+		SectionReader: io.NewSectionReader(a.reader, node.offset, node.size),
+	}, nil
+}
+
+// ReadDir returns the entries of the named folder sorted by name.
+func (a *Archive) ReadDir(name string) ([]fs.DirEntry, error) {
+	node, err := a.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !node.isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: eris.New("not a directory")}
+	}
+
+	entries := node.dirEntries()
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Name() < entries[b].Name()
+	})
+
+	return entries, nil
+}
+
+// ReadFile returns the content of the named file.
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	node, err := a.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.isDir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: eris.New("is a directory")}
+	}
+
+	data := make([]byte, node.size)
+	_, err = a.reader.ReadAt(data, node.offset)
+	if err != nil && !(err == io.EOF && len(data) == 0) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+
+	return data, nil
+}
+
+func (e *entry) dirEntries() []fs.DirEntry {
+	result := make([]fs.DirEntry, len(e.children))
+	for idx, child := range e.children {
+		result[idx] = fileInfo{child}
+	}
+
+	return result
+}
+
+// fileInfo implements fs.FileInfo and fs.DirEntry.
+type fileInfo struct {
+	entry *entry
+}
+
+func (i fileInfo) Name() string       { return i.entry.name }
+func (i fileInfo) Size() int64        { return i.entry.size }
+func (i fileInfo) ModTime() time.Time { return i.entry.modTime }
+func (i fileInfo) IsDir() bool        { return i.entry.isDir }
+func (i fileInfo) Sys() interface{}   { return nil }
+
+func (i fileInfo) Mode() fs.FileMode {
+	if i.entry.isDir {
+		return fs.ModeDir | 0555
+	}
+
+	return 0444
+}
+
+func (i fileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+type file struct {
+	*io.SectionReader
+	entry *entry
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return fileInfo{f.entry}, nil }
+func (f *file) Close() error               { return nil }
+
+type dirFile struct {
+	entry  *entry
+	offset int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) { return fileInfo{d.entry}, nil }
+func (d *dirFile) Close() error               { return nil }
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: eris.New("is a directory")}
+}
+
+func (d *dirFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	entries := d.entry.dirEntries()[d.offset:]
+	if count > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+
+		if len(entries) > count {
+			entries = entries[:count]
+		}
+	}
+
+	d.offset += len(entries)
+	return entries, nil
+}
+
+// readAll loads r into memory if it can't be read randomly.
+func readAll(r io.Reader) (io.ReaderAt, error) {
+	if readerAt, ok := r.(io.ReaderAt); ok {
+		return readerAt, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// Open opens the archive name inside fsys. The archive has to be closed after use.
+func Open(fsys fs.FS, name string) (*Archive, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open %s", name)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, eris.Wrapf(err, "failed to read %s", name)
+	}
+
+	reader, err := readAll(f)
+	if err != nil {
+		f.Close()
+		return nil, eris.Wrapf(err, "failed to read %s", name)
+	}
+
+	archive, err := NewReader(reader, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, eris.Wrapf(err, "failed to read %s", name)
+	}
+
+	archive.closer = f
+	return archive, nil
+}
@@ -0,0 +1,103 @@
+package vp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	modTime := time.Unix(1600000000, 0)
+	source := fstest.MapFS{
+		"data/tables/ships.tbl":      {Data: []byte("#Ship Classes\n#End\n"), ModTime: modTime},
+		"data/tables/mod-shp.tbm":    {Data: []byte("#Ship Classes\n#End\n"), ModTime: modTime},
+		"data/maps/cockpit.dds":      {Data: []byte("DDS "), ModTime: modTime},
+		"data/missions/sm1-01.fs2":   {Data: []byte("#Mission Info\n"), ModTime: modTime},
+		"data/missions/empty.fs2":    {Data: []byte{}, ModTime: modTime},
+		"data/effects/empty/.keep":   {Data: []byte{}, ModTime: modTime},
+		"readme.txt":                 {Data: []byte("hi"), ModTime: modTime},
+		"data/tables/nested/foo.tbm": {Data: []byte("foo"), ModTime: modTime},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, source); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = fstest.TestFS(archive,
+		"data/tables/ships.tbl",
+		"data/tables/mod-shp.tbm",
+		"data/maps/cockpit.dds",
+		"data/missions/sm1-01.fs2",
+		"readme.txt",
+		"data/tables/nested/foo.tbm",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.ReadFile(archive, "Data/Tables/SHIPS.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "#Ship Classes\n#End\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	info, err := fs.Stat(archive, "data/tables/ships.tbl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("unexpected timestamp %v", info.ModTime())
+	}
+
+	if _, err := fs.Stat(archive, "data/effects"); err == nil {
+		t.Error("empty folders should be skipped")
+	}
+}
+
+func TestTruncatedArchive(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, fstest.MapFS{
+		"data/tables/ships.tbl": {Data: []byte("#Ship Classes\n#End\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	truncated := data[:len(data)-entrySize]
+	if _, err := NewReader(bytes.NewReader(truncated), int64(len(truncated))); err == nil {
+		t.Error("expected an error for a truncated directory")
+	}
+
+	// A huge entry count has to be rejected before the directory is allocated
+	hostile := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(hostile[12:16], 0x7fffffff)
+	if _, err := NewReader(bytes.NewReader(hostile), int64(len(hostile))); err == nil {
+		t.Error("expected an error for an entry count exceeding the archive")
+	}
+
+	// File contents outside of the archive
+	outside := append([]byte{}, data...)
+	dirOffset := int(binary.LittleEndian.Uint32(outside[8:12]))
+	for idx := dirOffset; idx+entrySize <= len(outside); idx += entrySize {
+		if binary.LittleEndian.Uint32(outside[idx+4:idx+8]) > 0 {
+			binary.LittleEndian.PutUint32(outside[idx+4:idx+8], 0x7fffffff)
+		}
+	}
+	if _, err := NewReader(bytes.NewReader(outside), int64(len(outside))); err == nil {
+		t.Error("expected an error for a file exceeding the archive")
+	}
+}
@@ -0,0 +1,158 @@
+package vp
+
+import (
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/rotisserie/eris"
+)
+
+// Write packs every file in fsys into a VP archive written to w. Folders are written in
+// alphabetical order. Empty files and folders are skipped since the format can't tell them
+// apart.
+func Write(w io.Writer, fsys fs.FS) error {
+	root := &entry{name: ".", isDir: true}
+	dataSize, err := collect(fsys, ".", root)
+	if err != nil {
+		return err
+	}
+
+	directory := make([]dirEntry, 0)
+	files := make([]*entry, 0)
+	offset := int64(headerSize)
+	if err := buildDirectory(root, ".", &offset, &directory, &files); err != nil {
+		return err
+	}
+
+	dirOffset := headerSize + dataSize
+	if dirOffset > 1<<31-1 {
+		return eris.New("the archive would exceed the maximum size of 2 GiB")
+	}
+
+	hdr := header{
+		Signature: signature,
+		Version:   version,
+		DirOffset: int32(dirOffset),
+		DirCount:  int32(len(directory)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, &hdr); err != nil {
+		return eris.Wrap(err, "failed to write header")
+	}
+
+	for _, file := range files {
+		if err := copyFile(w, fsys, file); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, directory); err != nil {
+		return eris.Wrap(err, "failed to write directory")
+	}
+
+	return nil
+}
+
+// collect builds the tree of folders and files below dir and returns the total size of all files.
+func collect(fsys fs.FS, dir string, parent *entry) (int64, error) {
+	items, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return 0, eris.Wrapf(err, "failed to list %s", dir)
+	}
+
+	sort.Slice(items, func(a, b int) bool {
+		return items[a].Name() < items[b].Name()
+	})
+
+	var total int64
+	for _, item := range items {
+		if len(item.Name()) >= nameSize {
+			return 0, eris.Errorf("the name %s is too long, VP names are limited to %d characters", path.Join(dir, item.Name()), nameSize-1)
+		}
+
+		info, err := item.Info()
+		if err != nil {
+			return 0, eris.Wrapf(err, "failed to stat %s", path.Join(dir, item.Name()))
+		}
+
+		node := &entry{
+			name:    item.Name(),
+			modTime: info.ModTime(),
+			isDir:   item.IsDir(),
+		}
+
+		if node.isDir {
+			size, err := collect(fsys, path.Join(dir, item.Name()), node)
+			if err != nil {
+				return 0, err
+			}
+
+			if len(node.children) == 0 {
+				continue
+			}
+			total += size
+		} else {
+			if !info.Mode().IsRegular() || info.Size() == 0 {
+				continue
+			}
+
+			node.size = info.Size()
+			total += node.size
+		}
+
+		parent.children = append(parent.children, node)
+	}
+
+	return total, nil
+}
+
+// buildDirectory appends the directory entries for dir. files receives the files in the order
+// their contents have to be written, named by their full path.
+func buildDirectory(dir *entry, dirPath string, offset *int64, directory *[]dirEntry, files *[]*entry) error {
+	for _, child := range dir.children {
+		item := dirEntry{}
+		copy(item.Name[:], child.name)
+
+		if child.isDir {
+			*directory = append(*directory, item)
+			if err := buildDirectory(child, path.Join(dirPath, child.name), offset, directory, files); err != nil {
+				return err
+			}
+
+			back := dirEntry{}
+			copy(back.Name[:], "..")
+			*directory = append(*directory, back)
+			continue
+		}
+
+		if child.size > 1<<31-1 {
+			return eris.Errorf("%s is too large for a VP archive", path.Join(dirPath, child.name))
+		}
+
+		item.Offset = int32(*offset)
+		item.Size = int32(child.size)
+		item.Timestamp = int32(child.modTime.Unix())
+		*directory = append(*directory, item)
+		*files = append(*files, &entry{name: path.Join(dirPath, child.name), size: child.size})
+		*offset += child.size
+	}
+
+	return nil
+}
+
+func copyFile(w io.Writer, fsys fs.FS, file *entry) error {
+	f, err := fsys.Open(file.name)
+	if err != nil {
+		return eris.Wrapf(err, "failed to open %s", file.name)
+	}
+	defer f.Close()
+
+	// Copy exactly the size recorded in the directory even if the file changed in the meantime
+	if _, err := io.CopyN(w, f, file.size); err != nil {
+		return eris.Wrapf(err, "failed to copy %s", file.name)
+	}
+
+	return nil
+}